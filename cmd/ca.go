@@ -0,0 +1,137 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/ca"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	defaultCACert = os.ExpandEnv("$HOME/.config/atest/collector-ca.crt")
+	defaultCAKey  = os.ExpandEnv("$HOME/.config/atest/collector-ca.key")
+)
+
+type caOption struct {
+	cert string
+	key  string
+}
+
+func (o *caOption) setFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.cert, "ca-cert", "", defaultCACert, "The certificate file of the local CA")
+	flags.StringVarP(&o.key, "ca-key", "", defaultCAKey, "The private key file of the local CA")
+}
+
+func createCACmd() (c *cobra.Command) {
+	c = &cobra.Command{
+		Use:   "ca",
+		Short: "Manage the local CA which is used to intercept HTTPS traffic",
+	}
+	c.AddCommand(createCAGenerateCmd(), createCAExportCmd())
+	return
+}
+
+type caGenerateOption struct {
+	caOption
+	commonName string
+	validity   time.Duration
+	force      bool
+}
+
+func createCAGenerateCmd() (c *cobra.Command) {
+	opt := &caGenerateOption{}
+	c = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a new local CA",
+		RunE:  opt.runE,
+	}
+	flags := c.Flags()
+	opt.setFlags(flags)
+	flags.StringVarP(&opt.commonName, "common-name", "", ca.DefaultCommonName, "The common name of the CA")
+	flags.DurationVarP(&opt.validity, "validity", "", 10*365*24*time.Hour, "The validity of the CA")
+	flags.BoolVarP(&opt.force, "force", "f", false, "Overwrite the existing CA")
+	return
+}
+
+func (o *caGenerateOption) runE(c *cobra.Command, args []string) (err error) {
+	if _, statErr := os.Stat(o.cert); statErr == nil && !o.force {
+		err = fmt.Errorf("the CA %q already exists, use --force to overwrite it", o.cert)
+		return
+	}
+
+	var authority *ca.Authority
+	if authority, err = ca.Generate(o.commonName, o.validity); err != nil {
+		return
+	}
+	if err = authority.Save(o.cert, o.key); err == nil {
+		c.Println("The CA is generated into", o.cert)
+		c.Println("SHA-256 fingerprint:", authority.Fingerprint())
+		c.Println("Please trust it in your browser or system before intercepting HTTPS traffic")
+	}
+	return
+}
+
+type caExportOption struct {
+	caOption
+	output string
+	format string
+}
+
+func createCAExportCmd() (c *cobra.Command) {
+	opt := &caExportOption{}
+	c = &cobra.Command{
+		Use:   "export",
+		Short: "Export the certificate of the local CA, it could be trusted by the browser or system",
+		RunE:  opt.runE,
+	}
+	flags := c.Flags()
+	opt.setFlags(flags)
+	flags.StringVarP(&opt.output, "output", "o", "", "The output file, print to stdout if it is empty")
+	flags.StringVarP(&opt.format, "format", "", "pem", "The certificate format, supported: pem, der")
+	return
+}
+
+func (o *caExportOption) runE(c *cobra.Command, args []string) (err error) {
+	var authority *ca.Authority
+	if authority, err = ca.Load(o.cert, o.key); err != nil {
+		err = fmt.Errorf("failed to load the CA, please generate it first: %v", err)
+		return
+	}
+
+	var data []byte
+	switch o.format {
+	case "pem":
+		data = authority.CertificatePEM()
+	case "der":
+		data = authority.CertificateDER()
+	default:
+		err = fmt.Errorf("not support format: %q", o.format)
+		return
+	}
+
+	if o.output == "" {
+		_, err = c.OutOrStdout().Write(data)
+	} else {
+		err = os.WriteFile(o.output, data, 0644)
+	}
+	return
+}
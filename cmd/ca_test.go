@@ -0,0 +1,57 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCACmd(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	caFlags := []string{"--ca-cert", certFile, "--ca-key", keyFile}
+
+	c := CreateRootCmd()
+	buf := new(bytes.Buffer)
+	c.SetOut(buf)
+
+	c.SetArgs(append([]string{"ca", "export"}, caFlags...))
+	assert.Error(t, c.Execute())
+
+	c.SetArgs(append([]string{"ca", "generate"}, caFlags...))
+	assert.NoError(t, c.Execute())
+	assert.FileExists(t, certFile)
+	assert.FileExists(t, keyFile)
+
+	c.SetArgs(append([]string{"ca", "generate"}, caFlags...))
+	assert.Error(t, c.Execute())
+
+	output := filepath.Join(dir, "export.pem")
+	c.SetArgs(append([]string{"ca", "export", "--output", output}, caFlags...))
+	assert.NoError(t, c.Execute())
+	expected, _ := os.ReadFile(certFile)
+	actual, _ := os.ReadFile(output)
+	assert.Equal(t, expected, actual)
+
+	c.SetArgs(append([]string{"ca", "export", "--format", "fake"}, caFlags...))
+	assert.Error(t, c.Execute())
+}
@@ -21,85 +21,342 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
-	"strings"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/elazarl/goproxy"
 	"github.com/elazarl/goproxy/ext/auth"
 	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/ca"
 	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+	"github.com/linuxsuren/atest-ext-collector/pkg/rpc"
+	"github.com/linuxsuren/atest-ext-collector/pkg/session"
+	"github.com/linuxsuren/atest-ext-collector/pkg/sink"
+	"github.com/linuxsuren/atest-ext-collector/pkg/ui"
+	"github.com/linuxsuren/atest-ext-collector/pkg/websocket"
 	"github.com/spf13/cobra"
 )
 
 type option struct {
+	caOption
+	policyOption
+	grpcOption
+	sinkOption
+	controlOption
+	filterOption
 	port             int
-	filterPath       []string
 	saveResponseBody bool
 	output           string
+	harOutput        string
+	postmanOutput    string
+	k6Output         string
+	websocketOutput  string
+	websocketFormat  string
+	journal          string
+	resume           bool
+	flushInterval    time.Duration
+	maxBodySize      int64
+	queueSize        int
+	stopTimeout      time.Duration
+	overflow         string
+	normalizePath    bool
+	bodyFingerprint  bool
+	inferSchema      bool
+	schemaDir        string
 	upstreamProxy    string
 	verbose          bool
 	username         string
 	password         string
+	mitm             bool
+	mitmHosts        []string
 }
 
 // createCollectorCmd creates the collector command
 func createCollectorCmd() (c *cobra.Command) {
 	opt := &option{}
 	c = &cobra.Command{
-		Use:   "collector",
-		Short: "A collector for API testing, it will start a HTTP proxy server",
-		RunE:  opt.runE,
+		Use:     "collector",
+		Short:   "A collector for API testing, it will start a HTTP proxy server",
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
 	}
 	flags := c.Flags()
 	flags.IntVarP(&opt.port, "port", "p", 8080, "The port for the proxy")
-	flags.StringSliceVarP(&opt.filterPath, "filter-path", "", []string{}, "The path prefix for filtering")
 	flags.BoolVarP(&opt.saveResponseBody, "save-response-body", "", false, "Save the response body")
 	flags.StringVarP(&opt.output, "output", "o", "sample.yaml", "The output file")
+	flags.StringVarP(&opt.harOutput, "har", "", "", "The output HAR file, it will not be written if it is empty")
+	flags.StringVarP(&opt.postmanOutput, "postman", "", "",
+		"The output Postman collection file, it will not be written if it is empty")
+	flags.StringVarP(&opt.k6Output, "k6", "", "",
+		"The output k6 load-test script, it will not be written if it is empty")
+	flags.StringVarP(&opt.websocketOutput, "websocket", "", "",
+		"The output file of the plain ws:// sessions, they will not be captured if it is empty. The wss:// sessions are forwarded without recording")
+	flags.StringVarP(&opt.websocketFormat, "websocket-format", "", websocket.FormatJSON,
+		"The format of the WebSocket sessions, supported: json (transcript), k6 (replayable script)")
+	flags.StringVarP(&opt.journal, "journal", "", "",
+		"The append-only journal file which keeps every captured request with the raw headers, it is written only if it is given or resuming")
+	flags.BoolVarP(&opt.resume, "resume", "", false,
+		"Resume the previous session from the journal file, default is the output file with .journal suffix")
+	flags.DurationVarP(&opt.flushInterval, "flush-interval", "", 0,
+		"The minimum interval to rewrite the output files, they are rewritten after each request if it is zero")
+	flags.Int64VarP(&opt.maxBodySize, "max-body-size", "", pkg.DefaultMaxBodySize,
+		"The max bytes of a recorded response body, the rest is forwarded without recording. There is no limit if it is zero")
+	flags.IntVarP(&opt.queueSize, "queue-size", "", pkg.DefaultQueueSize,
+		"The max number of the new requests which are waiting to be written into the outputs")
+	flags.DurationVarP(&opt.stopTimeout, "stop-timeout", "", pkg.DefaultStopTimeout,
+		"The max time to close the connections and write the pending requests into the outputs when stopping")
+	flags.StringVarP(&opt.overflow, "overflow", "", string(pkg.OverflowDrop),
+		"What to do with the new requests when the queue is full, supported: drop, block (slows down the proxy), spill (to a temporary file)")
+	flags.BoolVarP(&opt.normalizePath, "normalize-path", "", false,
+		"Deduplicate the requests by the normalized path, the numeric, UUID and hash segments are taken as parameters")
+	flags.BoolVarP(&opt.bodyFingerprint, "body-fingerprint", "", false,
+		"Deduplicate the requests by the request body as well, it implies --normalize-path")
+	flags.BoolVarP(&opt.inferSchema, "infer-schema", "", false,
+		"Infer the JSON schemas of the responses by merging all the observed bodies of each test case")
+	flags.StringVarP(&opt.schemaDir, "schema-dir", "", "",
+		"The directory to write the inferred schemas into, they are embedded into the test cases if it is empty. It implies --infer-schema")
 	flags.StringVarP(&opt.upstreamProxy, "upstream-proxy", "", "", "The upstream proxy")
 	flags.StringVarP(&opt.username, "username", "", "", "The username for basic auth")
 	flags.StringVarP(&opt.password, "password", "", "", "The password for basic auth")
 	flags.BoolVarP(&opt.verbose, "verbose", "", false, "Verbose mode")
+	flags.BoolVarP(&opt.mitm, "mitm", "", false, "Intercept the HTTPS traffic with the local CA, see the ca command")
+	flags.StringSliceVarP(&opt.mitmHosts, "mitm-host", "", []string{},
+		"The host patterns (regexp) to intercept, all hosts will be intercepted if it is empty")
+	opt.caOption.setFlags(flags)
+	opt.policyOption.setFlags(flags)
+	opt.grpcOption.setFlags(flags)
+	opt.sinkOption.setFlags(flags)
+	opt.controlOption.setFlags(flags)
+	opt.filterOption.setFlags(flags)
+	return
+}
 
-	_ = cobra.MarkFlagRequired(flags, "filter-path")
+func (o *option) preRunE(cmd *cobra.Command, args []string) (err error) {
+	if err = o.policyOption.preRunE(cmd, args); err == nil {
+		err = o.grpcOption.validate()
+	}
+	if err == nil {
+		err = validateOverflowPolicy(o.overflow)
+	}
+	if err == nil {
+		err = o.sinkOption.validate()
+	}
+	if err == nil {
+		err = o.filterOption.validate()
+	}
 	return
 }
 
+// journalFile returns the journal file, it is empty if the journal is not required. The journal keeps the raw
+// headers to resume the session as it was, including the secrets, then it is written only if it is asked for.
+func (o *option) journalFile() string {
+	if o.journal == "" && o.resume {
+		return o.output + ".journal"
+	}
+	return o.journal
+}
+
+// newCaseExporter creates the test suite exporter of the output, the sinks, the sessions and the web UI,
+// then all of them export the same test cases
+func (o *option) newCaseExporter() *pkg.SampleExporter {
+	return o.newSampleExporter(o.saveResponseBody).WithPathParams(o.normalizePath || o.bodyFingerprint)
+}
+
+// proxyRealm is the realm of the proxy basic auth
+const proxyRealm = "my_realm"
+
+// proxyAuth returns the checker of the proxy credentials, it returns nil if there is no credential required
+func (o *option) proxyAuth() func(user, password string) bool {
+	if o.username == "" || o.password == "" {
+		return nil
+	}
+	return func(user, password string) bool {
+		return user == o.username && password == o.password
+	}
+}
+
+// authorizedTunnel is the user data of the CONNECT requests which passed the proxy basic auth
+type authorizedTunnel struct{}
+
+// proxyBasic checks the proxy credentials like auth.ProxyBasic, but the CONNECT handlers after it keep working,
+// and the requests inside of the intercepted tunnels are trusted because they carry no proxy credentials
+func proxyBasic(proxy *goproxy.ProxyHttpServer, realm string, check func(user, password string) bool) {
+	basic, basicConnect := auth.Basic(realm, check), auth.BasicConnect(realm, check)
+	proxy.OnRequest().DoFunc(func(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+		if _, ok := ctx.UserData.(authorizedTunnel); ok || ca.IsIntercepted(req) {
+			return req, nil
+		}
+		return basic.Handle(req, ctx)
+	})
+	proxy.OnRequest().HandleConnectFunc(func(host string, ctx *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
+		if action, _ := basicConnect.HandleConnect(host, ctx); action == goproxy.RejectConnect {
+			return action, host
+		}
+		// the MITM requests inherit the user data of the CONNECT request
+		ctx.UserData = authorizedTunnel{}
+		return nil, host
+	})
+}
+
+func validateOverflowPolicy(policy string) error {
+	for _, item := range pkg.OverflowPolicies {
+		if policy == string(item) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported overflow policy %q, supported: %v", policy, pkg.OverflowPolicies)
+}
+
+// exchange keeps the data between the request and response handlers
+type exchange struct {
+	startedAt   time.Time
+	requestBody []byte
+}
+
+// captureRequest keeps the request body, it will be consumed when sending the request
+func captureRequest(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+	ex := &exchange{startedAt: time.Now()}
+	if req.Body != nil && req.Body != http.NoBody {
+		if data, err := io.ReadAll(req.Body); err == nil {
+			ex.requestBody = data
+			req.Body = io.NopCloser(bytes.NewReader(data))
+		}
+	}
+	ctx.UserData = ex
+	return req, nil
+}
+
 type responseFilter struct {
-	urlFilter *filter.URLPathFilter
-	collects  *pkg.Collects
-	ctx       context.Context
+	requestFilter filter.Filter
+	contentPolicy *pkg.ContentTypePolicy
+	collects      *pkg.Collects
+	journal       *pkg.Journal
+	sessions      *session.Manager
+	maxBodySize   int64
+	ctx           context.Context
 }
 
+// filter records the response once its body is forwarded, then the streams are not blocked
 func (f *responseFilter) filter(resp *http.Response, ctx *goproxy.ProxyCtx) *http.Response {
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.Contains(contentType, "application/json") {
+	if resp == nil {
+		return resp
+	}
+
+	contentPolicy := f.contentPolicy
+	if contentPolicy == nil {
+		contentPolicy = pkg.NewContentTypePolicy()
+	}
+	if !contentPolicy.Match(resp.Header.Get("Content-Type")) {
 		return resp
 	}
 
 	req := resp.Request
-	if f.urlFilter.Filter(req.URL) {
-		simpleResp := &pkg.SimpleResponse{StatusCode: resp.StatusCode}
+	target := &filter.Exchange{Method: req.Method, URL: req.URL, StatusCode: resp.StatusCode}
+	collected := f.requestFilter.Match(target)
+	recorded := f.sessions != nil && f.sessions.Match(target)
+	if collected || recorded {
+		simpleResp := &pkg.SimpleResponse{StatusCode: resp.StatusCode, Header: resp.Header.Clone()}
 
-		if resp.Body != nil {
-			buf := new(bytes.Buffer)
-			io.Copy(buf, resp.Body)
-			simpleResp.Body = buf.String()
-			resp.Body = io.NopCloser(buf)
+		var ex *exchange
+		startedAt := time.Now()
+		if ctx != nil {
+			var ok bool
+			if ex, ok = ctx.UserData.(*exchange); ok {
+				startedAt = ex.startedAt
+			}
+		}
+		simpleResp.StartedAt = startedAt
+		// each consumer has its own clone, then the bodies are not read concurrently
+		clone := func() *http.Request {
+			clone := req.Clone(f.ctx)
+			if ex != nil {
+				clone.Body = io.NopCloser(bytes.NewReader(decodeBody(ex.requestBody, req.Header.Get("Content-Encoding"), false)))
+			}
+			return clone
 		}
 
-		f.collects.Add(req.Clone(f.ctx), simpleResp)
+		record := func() {
+			simpleResp.Duration = time.Since(startedAt)
+			if collected {
+				request := clone()
+				if f.journal != nil {
+					f.journal.Add(&pkg.RequestAndResponse{Request: request, Response: simpleResp})
+				}
+				f.collects.Add(request, simpleResp)
+			}
+			if recorded {
+				f.sessions.Add(clone(), simpleResp)
+			}
+		}
+		if resp.Body == nil {
+			record()
+		} else {
+			resp.Body = pkg.CaptureBody(resp.Body, f.maxBodySize, func(data []byte, truncated bool) {
+				simpleResp.Body = string(decodeBody(data, resp.Header.Get("Content-Encoding"), truncated))
+				simpleResp.Truncated = truncated
+				record()
+			})
+		}
 	}
 	return resp
 }
 
+// newKeyStrategy creates the key strategy to deduplicate the requests, the GraphQL
+// requests are sent to the same URL, they are deduplicated by the operations
+func newKeyStrategy(normalizePath, bodyFingerprint bool) pkg.KeyStrategy {
+	var strategy pkg.KeyStrategy = &pkg.URLKeyStrategy{}
+	if normalizePath || bodyFingerprint {
+		strategy = &pkg.NormalizedKeyStrategy{BodyFingerprint: bodyFingerprint}
+	}
+	return &pkg.GraphQLKeyStrategy{Fallback: strategy}
+}
+
+// decodeBody decodes the recorded body, the forwarded one is untouched. The Content-Encoding header is kept,
+// then the original encoding is known. The truncated bodies are decoded as much as possible.
+func decodeBody(data []byte, contentEncoding string, truncated bool) []byte {
+	decoded, err := pkg.DecodeBody(data, contentEncoding)
+	if err != nil && !(truncated && len(decoded) > 0) {
+		log.Println("failed to decode the body:", err)
+		return data
+	}
+	return decoded
+}
+
 func (o *option) runE(cmd *cobra.Command, args []string) (err error) {
-	urlFilter := &filter.URLPathFilter{PathPrefix: o.filterPath}
+	journalFile := o.journalFile()
+	var replayed []*pkg.RequestAndResponse
+	if o.resume {
+		if replayed, err = pkg.ReadJournal(journalFile); err != nil {
+			return
+		}
+	}
+
+	var journal *pkg.Journal
+	if journalFile != "" {
+		if journal, err = pkg.OpenJournal(journalFile, o.resume); err != nil {
+			return
+		}
+		defer func() {
+			_ = journal.Close()
+		}()
+	}
+
 	collects := pkg.NewCollects()
-	responseFilter := &responseFilter{urlFilter: urlFilter, collects: collects, ctx: cmd.Context()}
+	collects.SetQueueSize(o.queueSize)
+	responseFilter := &responseFilter{
+		requestFilter: o.filter,
+		contentPolicy: o.contentPolicy,
+		collects:      collects,
+		journal:       journal,
+		maxBodySize:   o.maxBodySize,
+		ctx:           cmd.Context(),
+	}
 
 	proxy := goproxy.NewProxyHttpServer()
 	proxy.Verbose = o.verbose
@@ -110,34 +367,202 @@ func (o *option) runE(cmd *cobra.Command, args []string) (err error) {
 		proxy.ConnectDial = proxy.NewConnectDialToProxy(o.upstreamProxy)
 		cmd.Println("Using upstream proxy", o.upstreamProxy)
 	}
-	if o.username != "" && o.password != "" {
-		auth.ProxyBasic(proxy, "my_realm", func(user, pwd string) bool {
-			return user == o.username && o.password == pwd
-		})
+	if o.proxyAuth() != nil {
+		proxyBasic(proxy, proxyRealm, o.proxyAuth())
 	}
+	var grpcCollects *pkg.Collects
+	var grpcWriter *pkg.OutputWriter
+	var descriptors *rpc.Descriptors
+	if o.grpcPort > 0 || (o.mitm && o.hasDescriptors()) {
+		if descriptors, grpcCollects, grpcWriter, err = o.newGRPCRecorder(o.saveResponseBody, o.headerPolicy, o.flushInterval); err != nil {
+			return
+		}
+	}
+	if o.mitm {
+		var authority *ca.Authority
+		if authority, err = ca.Load(o.cert, o.key); err != nil {
+			err = fmt.Errorf("failed to load the CA, please run 'ca generate' first: %v", err)
+			return
+		}
+
+		var interceptor *ca.Interceptor
+		if interceptor, err = ca.NewInterceptor(authority, o.mitmHosts); err != nil {
+			return
+		}
+		if descriptors != nil {
+			// the gRPC calls are sent over HTTP/2 which goproxy does not read
+			interceptor.WithHTTP2(newMITMHandler(proxy, rpc.NewMITMProxy(descriptors, grpcCollects.Add,
+				proxy.Tr.TLSClientConfig, proxy.ConnectDial).WithMaxBodySize(o.maxBodySize)))
+		}
+		proxy.OnRequest().HandleConnectFunc(interceptor.ConnectFilter)
+		cmd.Println("Intercepting HTTPS traffic with the CA", o.cert)
+	}
+	proxy.OnRequest().DoFunc(captureRequest)
 	proxy.OnResponse().DoFunc(responseFilter.filter)
 
-	exporter := pkg.NewSampleExporter(o.saveResponseBody)
-	collects.AddEvent(exporter.Add)
+	collects.SetKeyStrategy(newKeyStrategy(o.normalizePath, o.bodyFingerprint))
+	sessions := session.NewManager(session.Options{
+		Exporter: func() pkg.Exporter {
+			return o.newCaseExporter()
+		},
+		KeyStrategy: func() pkg.KeyStrategy {
+			return newKeyStrategy(o.normalizePath, o.bodyFingerprint)
+		},
+	})
+	store := ui.NewStore(o.newCaseExporter).WithHeaderPolicy(o.headerPolicy)
+	controlSrv := o.newControlServer(sessions, store, o.proxyAuth())
+	if controlSrv != nil {
+		responseFilter.sessions = sessions
+		collects.AddEvent(store.Add)
+		go func() {
+			cmd.Println("Starting the web UI and the control API on", controlSrv.Addr)
+			if err := controlSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				cmd.PrintErrln("failed to start the control API", err)
+			}
+		}()
+	}
+	writer := pkg.NewOutputWriter(o.flushInterval)
+	exporter, raw := o.newExporter(o.newCaseExporter)
+	if sampleExporter, ok := exporter.(*pkg.SampleExporter); ok {
+		if o.inferSchema || o.schemaDir != "" {
+			schemas := pkg.NewSchemaInferrer()
+			collects.AddRawEvent(schemas.Add)
+			sampleExporter.WithSchemas(schemas, o.schemaDir).WithOutputDir(filepath.Dir(o.output))
+		}
+	}
+	if o.harOutput != "" {
+		harExporter := pkg.NewHARExporter()
+		collects.AddEvent(harExporter.Add)
+		writer.AddOutput(o.harOutput, harExporter)
+	}
+	if o.postmanOutput != "" {
+		postmanExporter := o.newPostmanExporter()
+		collects.AddRawEvent(postmanExporter.Add)
+		collects.AddRawEvent(writer.Touch)
+		writer.AddOutput(o.postmanOutput, postmanExporter)
+	}
+	if o.k6Output != "" {
+		k6Exporter := o.newK6Exporter()
+		collects.AddRawEvent(k6Exporter.Add)
+		collects.AddRawEvent(writer.Touch)
+		writer.AddOutput(o.k6Output, k6Exporter)
+	}
+	collects.AddEvent(writer.Add)
+
+	// the output is the default file sink, the schemas are inferred before it takes the duplicated requests
+	var sinks []*sink.Queue
+	if sinks, err = o.openSinks(collects, sink.Config{
+		Target: o.output,
+		Exporter: func() pkg.Exporter {
+			return exporter
+		},
+		HeaderPolicy:  o.headerPolicy,
+		Raw:           raw,
+		FlushInterval: o.flushInterval,
+	}, sink.Config{
+		Exporter: func() pkg.Exporter {
+			return o.newCaseExporter()
+		},
+		HeaderPolicy:  o.headerPolicy,
+		FlushInterval: o.flushInterval,
+	}); err != nil {
+		return
+	}
+
+	// nothing should be dropped when replaying the journal
+	collects.SetOverflowPolicy(pkg.OverflowBlock)
+	for _, item := range replayed {
+		collects.Add(item.Request, item.Response)
+	}
+	collects.SetOverflowPolicy(pkg.OverflowPolicy(o.overflow))
+	if o.resume {
+		cmd.Println("Resumed", len(replayed), "requests from", journalFile)
+	}
+
+	var handler http.Handler = proxy
+	var wsWriter *pkg.OutputWriter
+	if o.websocketOutput != "" {
+		var wsExporter *websocket.Exporter
+		if wsExporter, err = websocket.NewExporter(o.websocketFormat); err != nil {
+			return
+		}
+		wsExporter.WithHeaderPolicy(o.headerPolicy)
+		// the sessions are written by their own writer, they are not in the events goroutine
+		wsWriter = pkg.NewOutputWriter(o.flushInterval)
+		wsWriter.AddOutput(o.websocketOutput, wsExporter)
+		wsHandler := websocket.NewHandler(proxy, func(session *websocket.Session) {
+			wsExporter.AddSession(session)
+			wsWriter.Add(nil)
+		})
+		wsHandler.Filter = filter.URLFunc(o.filter)
+		// the captured sessions do not go through the proxy, then the credentials are checked by the handler
+		wsHandler.Auth, wsHandler.Realm = o.proxyAuth(), proxyRealm
+		if proxy.ConnectDial != nil {
+			wsHandler.Dial = proxy.ConnectDial
+		}
+		handler = wsHandler
+	}
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", o.port),
-		Handler: proxy,
+		Handler: flushEventStream(handler),
+	}
+
+	var grpcSrv *http.Server
+	if o.grpcPort > 0 {
+		if grpcSrv, err = o.newGRPCServer(descriptors, grpcCollects.Add, o.maxBodySize); err != nil {
+			return
+		}
+		go func() {
+			cmd.Println("Starting the gRPC proxy server with port", o.grpcPort, "for", o.grpcTarget)
+			if err := grpcSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				cmd.PrintErrln("failed to start the gRPC proxy server", err)
+			}
+		}()
 	}
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	shutdown := make(chan struct{})
 	go func() {
 		<-sig
-		collects.Stop()
-		_ = srv.Shutdown(context.Background())
+		// the long-lived connections are closed after the timeout, like the Server-Sent Events streams
+		ctx, cancel := context.WithTimeout(context.Background(), o.stopTimeout)
+		defer cancel()
+		for _, s := range []*http.Server{grpcSrv, controlSrv, srv} {
+			if s != nil && s.Shutdown(ctx) != nil {
+				_ = s.Close()
+			}
+		}
+		close(shutdown)
 	}()
 
 	cmd.Println("Starting the proxy server with port", o.port)
-	_ = srv.ListenAndServe()
-	var data string
-	if data, err = exporter.Export(); err == nil {
-		err = os.WriteFile(o.output, []byte(data), 0644)
+	if serveErr := srv.ListenAndServe(); serveErr == http.ErrServerClosed {
+		// the in-flight requests are still being recorded until the shutdown is done
+		<-shutdown
+	}
+	// deliver the pending requests to the exporters before writing the outputs
+	for _, c := range []*pkg.Collects{collects, grpcCollects} {
+		if c != nil {
+			if stopErr := c.StopWithTimeout(o.stopTimeout); stopErr != nil {
+				cmd.PrintErrln(stopErr)
+			}
+		}
+	}
+	if stopErr := sessions.Stop(); stopErr != nil {
+		cmd.PrintErrln(stopErr)
+	}
+	stats := collects.Stats()
+	cmd.Println("Collected", stats.Unique, "of", stats.Received, "requests, dropped", stats.Dropped,
+		"spilled", stats.Spilled)
+	err = closeSinks(sinks)
+	for _, w := range []*pkg.OutputWriter{writer, grpcWriter, wsWriter} {
+		if w != nil {
+			if flushErr := w.Flush(); err == nil {
+				err = flushErr
+			}
+		}
 	}
 	return
 }
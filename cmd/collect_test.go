@@ -17,15 +17,28 @@ limitations under the License.
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/elazarl/goproxy"
 	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/ca"
 	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+	"github.com/linuxsuren/atest-ext-collector/pkg/session"
+	"github.com/linuxsuren/atest-ext-collector/pkg/sink"
+	"github.com/linuxsuren/atest-ext-collector/pkg/ui"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,7 +64,7 @@ func TestResponseFilter(t *testing.T) {
 	emptyResp := &http.Response{}
 
 	filter := &responseFilter{
-		urlFilter: &filter.URLPathFilter{
+		requestFilter: &filter.URLPathFilter{
 			PathPrefix: []string{"/api/v1"},
 		},
 		collects: pkg.NewCollects(),
@@ -60,3 +73,417 @@ func TestResponseFilter(t *testing.T) {
 	filter.filter(emptyResp, nil)
 	filter.filter(resp, nil)
 }
+
+func TestResponseFilterWithContentPolicy(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://foo.com/api/v1/users", bytes.NewBufferString("name=rick"))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	ctx := &goproxy.ProxyCtx{}
+	req, _ = captureRequest(req, ctx)
+	// the body is consumed when sending the request
+	_, _ = io.ReadAll(req.Body)
+
+	collects := pkg.NewCollects()
+	received := make(chan *pkg.RequestAndResponse, 1)
+	collects.AddEvent(func(r *pkg.RequestAndResponse) {
+		received <- r
+	})
+	defer collects.Stop()
+
+	journalFile := filepath.Join(t.TempDir(), "sample.journal")
+	journal, err := pkg.OpenJournal(journalFile, false)
+	assert.NoError(t, err)
+
+	filter := &responseFilter{
+		requestFilter: &filter.URLPathFilter{PathPrefix: []string{"/api/v1"}},
+		contentPolicy: &pkg.ContentTypePolicy{Allow: []string{"text/*"}, Deny: []string{"text/html"}},
+		collects:      collects,
+		journal:       journal,
+		ctx:           context.Background(),
+	}
+	filter.filter(&http.Response{
+		Header:  http.Header{"Content-Type": []string{"text/html"}},
+		Request: req,
+		Body:    io.NopCloser(bytes.NewBufferString("<html/>")),
+	}, ctx)
+	resp := filter.filter(&http.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Content-Type": []string{"text/plain"}},
+		Request:    req,
+		Body:       io.NopCloser(bytes.NewBufferString("created")),
+	}, ctx)
+
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "created", string(data))
+
+	r := <-received
+	assert.Equal(t, "created", r.Response.Body)
+	assert.Equal(t, http.StatusCreated, r.Response.StatusCode)
+	assert.Equal(t, "name=rick", string(r.ReadRequestBody()))
+
+	assert.NoError(t, journal.Close())
+	items, err := pkg.ReadJournal(journalFile)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "name=rick", string(items[0].ReadRequestBody()))
+		assert.Equal(t, "created", items[0].Response.Body)
+	}
+}
+
+func TestResponseFilterWithStream(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://foo.com/api/v1/events", nil)
+	assert.NoError(t, err)
+
+	collects := pkg.NewCollects()
+	received := make(chan *pkg.RequestAndResponse, 1)
+	collects.AddEvent(func(r *pkg.RequestAndResponse) {
+		received <- r
+	})
+	defer collects.Stop()
+
+	filter := &responseFilter{
+		requestFilter: &filter.URLPathFilter{PathPrefix: []string{"/api/v1"}},
+		contentPolicy: &pkg.ContentTypePolicy{Allow: []string{"text/event-stream"}},
+		collects:      collects,
+		maxBodySize:   20,
+		ctx:           context.Background(),
+	}
+
+	reader, writer := io.Pipe()
+	resp := filter.filter(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Request:    req,
+		Body:       reader,
+	}, &goproxy.ProxyCtx{})
+
+	// the event is forwarded before the stream ends
+	go func() {
+		_, _ = writer.Write([]byte("data: hello\n\n"))
+	}()
+	buf := make([]byte, 64)
+	n, err := resp.Body.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "data: hello\n\n", string(buf[:n]))
+	assert.Empty(t, received)
+
+	go func() {
+		_, _ = writer.Write([]byte("data: world\n\n"))
+		_ = writer.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "data: world\n\n", string(data))
+
+	r := <-received
+	assert.True(t, r.Response.Truncated)
+	assert.Equal(t, "data: hello\n\ndata: w", r.Response.Body)
+	assert.Equal(t, []pkg.ServerSentEvent{{Data: "hello"}}, r.Response.Events())
+}
+
+func TestResponseFilterWithEncoding(t *testing.T) {
+	gzipped := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(gzipped)
+	_, _ = gzipWriter.Write([]byte(`{"name":"rick"}`))
+	assert.NoError(t, gzipWriter.Close())
+	encoded := gzipped.Bytes()
+
+	req, err := http.NewRequest(http.MethodPost, "http://foo.com/api/v1/users", bytes.NewReader(encoded))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+	ctx := &goproxy.ProxyCtx{}
+	req, _ = captureRequest(req, ctx)
+
+	collects := pkg.NewCollects()
+	received := make(chan *pkg.RequestAndResponse, 1)
+	collects.AddEvent(func(r *pkg.RequestAndResponse) {
+		received <- r
+	})
+	defer collects.Stop()
+
+	filter := &responseFilter{
+		requestFilter: &filter.URLPathFilter{PathPrefix: []string{"/api/v1"}},
+		collects:      collects,
+		ctx:           context.Background(),
+	}
+	resp := filter.filter(&http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type":     []string{"application/json"},
+			"Content-Encoding": []string{"gzip"},
+		},
+		Request: req,
+		Body:    io.NopCloser(bytes.NewReader(encoded)),
+	}, ctx)
+
+	// the original bytes are forwarded
+	data, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, data)
+	data, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, data)
+
+	r := <-received
+	assert.Equal(t, `{"name":"rick"}`, r.Response.Body)
+	assert.Equal(t, "gzip", r.Response.Header.Get("Content-Encoding"))
+	assert.Equal(t, `{"name":"rick"}`, string(r.ReadRequestBody()))
+	assert.Equal(t, "gzip", r.Request.Header.Get("Content-Encoding"))
+}
+
+func TestValidateOverflowPolicy(t *testing.T) {
+	assert.NoError(t, validateOverflowPolicy("drop"))
+	assert.NoError(t, validateOverflowPolicy("spill"))
+	assert.Error(t, validateOverflowPolicy("fake"))
+}
+
+func TestSinkOption(t *testing.T) {
+	opt := &sinkOption{sink: []string{"fake"}}
+	assert.Error(t, opt.validate())
+
+	dir := t.TempDir()
+	target, output := filepath.Join(dir, "cases"), sink.Config{Target: filepath.Join(dir, "sample.yaml")}
+	opt = &sinkOption{sink: []string{"dir:" + target, "webhook:localhost"}}
+	assert.NoError(t, opt.validate())
+	collects := pkg.NewCollects()
+	_, err := opt.openSinks(collects, output, sink.Config{})
+	assert.Error(t, err)
+	_, err = opt.openSinks(collects, sink.Config{}, sink.Config{})
+	assert.Error(t, err)
+
+	opt = &sinkOption{sink: []string{"dir:" + target}}
+	assert.NoError(t, opt.validate())
+	sinks, err := opt.openSinks(collects, output, sink.Config{})
+	assert.NoError(t, err)
+	assert.Len(t, sinks, 2)
+	request, _ := http.NewRequest(http.MethodGet, "http://foo/api/users", nil)
+	collects.Add(request, &pkg.SimpleResponse{StatusCode: http.StatusOK})
+	assert.NoError(t, collects.StopWithTimeout(time.Second))
+	assert.NoError(t, closeSinks(sinks))
+
+	// the output is written by the default file sink
+	data, err := os.ReadFile(output.Target)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "api: http://foo/api/users")
+	assert.DirExists(t, filepath.Join(target, "users"))
+}
+
+func TestResponseFilterWithSessions(t *testing.T) {
+	collects := pkg.NewCollects()
+	sessions := session.NewManager(session.Options{})
+	s, err := sessions.Start("orders", []string{"/api/orders"}, nil)
+	assert.NoError(t, err)
+
+	filter := &responseFilter{
+		requestFilter: &filter.URLPathFilter{PathPrefix: []string{"/api/users"}},
+		collects:      collects,
+		sessions:      sessions,
+		ctx:           context.Background(),
+	}
+	for _, api := range []string{"http://foo.com/api/users", "http://foo.com/api/orders", "http://foo.com/api/items"} {
+		req, err := http.NewRequest(http.MethodGet, api, nil)
+		assert.NoError(t, err)
+		filter.filter(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    req,
+		}, &goproxy.ProxyCtx{})
+	}
+
+	assert.Equal(t, int64(1), collects.Stats().Received)
+	requests := s.Requests()
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "http://foo.com/api/orders", requests[0].URL)
+	}
+	assert.NoError(t, sessions.Stop())
+}
+
+func TestControlOption(t *testing.T) {
+	opt := &controlOption{}
+	assert.Nil(t, opt.newControlServer(session.NewManager(session.Options{}), ui.NewStore(nil), nil))
+	opt.controlPort, opt.controlAddress = 9091, "127.0.0.1"
+	srv := opt.newControlServer(session.NewManager(session.Options{}), ui.NewStore(nil), nil)
+	if assert.NotNil(t, srv) {
+		assert.Equal(t, "127.0.0.1:9091", srv.Addr)
+
+		for _, path := range []string{"/", "/api/v1/requests", session.APIPrefix} {
+			w := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusOK, w.Code, path)
+		}
+	}
+
+	// the token or the proxy credentials are required once any of them is given
+	opt.controlToken = "token"
+	srv = opt.newControlServer(session.NewManager(session.Options{}), ui.NewStore(nil), func(user, password string) bool {
+		return user == "admin" && password == "secret"
+	})
+	for _, item := range []struct {
+		user, password, authorization string
+		code                          int
+	}{
+		{code: http.StatusUnauthorized},
+		{authorization: "Bearer fake", code: http.StatusUnauthorized},
+		{user: "admin", password: "fake", code: http.StatusUnauthorized},
+		{authorization: "Bearer token", code: http.StatusOK},
+		{user: "any", password: "token", code: http.StatusOK},
+		{user: "admin", password: "secret", code: http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, session.APIPrefix, nil)
+		if item.authorization != "" {
+			req.Header.Set("Authorization", item.authorization)
+		} else if item.user != "" {
+			req.SetBasicAuth(item.user, item.password)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, req)
+		assert.Equal(t, item.code, w.Code, item)
+	}
+}
+
+func TestFilterOption(t *testing.T) {
+	opt := &filterOption{}
+	assert.NoError(t, opt.validate())
+	assert.True(t, opt.filter.Match(&filter.Exchange{Method: http.MethodGet, URL: &url.URL{Path: "/users"}}))
+
+	file := filepath.Join(t.TempDir(), "filter.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("exclude:\n  - method: [GET]"), 0644))
+	opt = &filterOption{filterPath: []string{"/api"}, filterFile: file}
+	assert.NoError(t, opt.validate())
+	assert.True(t, opt.filter.Match(&filter.Exchange{Method: http.MethodPost, URL: &url.URL{Path: "/api/users"}}))
+	assert.False(t, opt.filter.Match(&filter.Exchange{Method: http.MethodGet, URL: &url.URL{Path: "/api/users"}}))
+	assert.False(t, opt.filter.Match(&filter.Exchange{Method: http.MethodPost, URL: &url.URL{Path: "/users"}}))
+
+	opt.filterFile = "fake.yaml"
+	assert.Error(t, opt.validate())
+}
+
+func TestProxyBasicWithMITM(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer backend.Close()
+
+	authority, err := ca.Generate(ca.DefaultCommonName, time.Hour)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(authority.Certificate())
+
+	for name, http2 := range map[string]bool{"mitm": false, "http2": true} {
+		t.Run(name, func(t *testing.T) {
+			proxy := goproxy.NewProxyHttpServer()
+			proxy.Tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+			proxyBasic(proxy, proxyRealm, func(user, password string) bool {
+				return user == "admin" && password == "secret"
+			})
+			interceptor, err := ca.NewInterceptor(authority, nil)
+			assert.NoError(t, err)
+			if http2 {
+				interceptor.WithHTTP2(newMITMHandler(proxy, nil))
+			}
+			proxy.OnRequest().HandleConnectFunc(interceptor.ConnectFilter)
+			var captured []string
+			proxy.OnRequest().DoFunc(func(r *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+				captured = append(captured, r.URL.Path)
+				return r, nil
+			})
+			server := httptest.NewServer(proxy)
+			defer server.Close()
+
+			get := func(user *url.Userinfo) (code int, body string) {
+				proxyURL, _ := url.Parse(server.URL)
+				proxyURL.User = user
+				transport := &http.Transport{
+					Proxy:             http.ProxyURL(proxyURL),
+					TLSClientConfig:   &tls.Config{RootCAs: pool},
+					ForceAttemptHTTP2: http2,
+				}
+				defer transport.CloseIdleConnections()
+
+				resp, err := (&http.Client{Transport: transport}).Get(backend.URL + "/api/users")
+				if err != nil {
+					return
+				}
+				defer func() {
+					_ = resp.Body.Close()
+				}()
+				data, _ := io.ReadAll(resp.Body)
+				return resp.StatusCode, string(data)
+			}
+
+			code, body := get(url.UserPassword("admin", "secret"))
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "/api/users", body)
+			assert.Equal(t, []string{"/api/users"}, captured)
+
+			// the tunnel is rejected without the credentials
+			code, _ = get(url.UserPassword("admin", "fake"))
+			assert.NotEqual(t, http.StatusOK, code)
+			assert.Len(t, captured, 1)
+		})
+	}
+}
+
+func TestNewCaseExporter(t *testing.T) {
+	opt := &option{normalizePath: true}
+	opt.headerPolicy, opt.contentPolicy = pkg.NewHeaderPolicy(), &pkg.ContentTypePolicy{}
+	exporter, raw := opt.newExporter(opt.newCaseExporter)
+	assert.False(t, raw)
+
+	// the output, the sinks, the sessions and the web UI share the same path params
+	for _, e := range []pkg.Exporter{exporter, opt.newCaseExporter()} {
+		request, _ := http.NewRequest(http.MethodGet, "http://foo/api/users/1024", nil)
+		e.Add(&pkg.RequestAndResponse{Request: request})
+		items := e.(*pkg.SampleExporter).TestSuite.Items
+		if assert.Len(t, items, 1) {
+			assert.Equal(t, "http://foo/api/users/{{.param.userId}}", items[0].Request.API)
+		}
+	}
+}
+
+func TestJournalFile(t *testing.T) {
+	opt := &option{output: "sample.yaml"}
+	assert.Empty(t, opt.journalFile())
+
+	opt.resume = true
+	assert.Equal(t, "sample.yaml.journal", opt.journalFile())
+
+	opt.journal = "session.journal"
+	assert.Equal(t, "session.journal", opt.journalFile())
+}
+
+func TestFlushEventStream(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(flushEventStream(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hijack" {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if assert.NoError(t, err) {
+				_, _ = conn.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
+				_ = conn.Close()
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("data: hello\n\n"))
+		<-release
+	})))
+	defer server.Close()
+	defer close(release)
+
+	resp, err := http.Get(server.URL + "/events")
+	assert.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	// the event is received before the stream ends
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "data: hello\n", line)
+
+	resp, err = http.Get(server.URL + "/hijack")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
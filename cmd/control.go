@@ -0,0 +1,86 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/session"
+	"github.com/linuxsuren/atest-ext-collector/pkg/ui"
+	"github.com/spf13/pflag"
+)
+
+// controlOption is the options of the control API, it is disabled if the port is zero
+type controlOption struct {
+	controlPort    int
+	controlAddress string
+	controlToken   string
+}
+
+func (o *controlOption) setFlags(flags *pflag.FlagSet) {
+	flags.IntVarP(&o.controlPort, "control-port", "", 0,
+		"The port of the web UI and the HTTP API to control the recording sessions, they will not be started if it is zero")
+	flags.StringVarP(&o.controlAddress, "control-address", "", "127.0.0.1",
+		"The address of the web UI and the control API to listen on, use 0.0.0.0 to expose them on all interfaces")
+	flags.StringVarP(&o.controlToken, "control-token", "", "",
+		"The token of the web UI and the control API, it is the bearer token or the password of the basic auth")
+}
+
+// newControlServer creates the server of the control API and the web UI, it returns nil if it is disabled.
+// The requests need the token or the proxy credentials if any of them is given.
+func (o *controlOption) newControlServer(manager *session.Manager, store *ui.Store,
+	proxyAuth func(user, password string) bool) (srv *http.Server) {
+	if o.controlPort > 0 {
+		sessionHandler := session.NewHandler(manager)
+		mux := http.NewServeMux()
+		mux.Handle(session.APIPrefix, sessionHandler)
+		mux.Handle(session.APIPrefix+"/", sessionHandler)
+		mux.Handle("/", ui.NewHandler(store))
+		srv = &http.Server{
+			Addr:    net.JoinHostPort(o.controlAddress, strconv.Itoa(o.controlPort)),
+			Handler: o.authorize(mux, proxyAuth),
+		}
+	}
+	return
+}
+
+// authorize checks the token and the proxy credentials, the requests are not checked if neither of them is given
+func (o *controlOption) authorize(next http.Handler, proxyAuth func(user, password string) bool) http.Handler {
+	if o.controlToken == "" && proxyAuth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, basic := r.BasicAuth()
+		switch {
+		case o.controlToken != "" && (secureEqual(r.Header.Get("Authorization"), "Bearer "+o.controlToken) ||
+			basic && secureEqual(password, o.controlToken)):
+		case proxyAuth != nil && basic && proxyAuth(user, password):
+		default:
+			w.Header().Set("WWW-Authenticate", `Basic realm="atest-collector"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
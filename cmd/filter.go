@@ -0,0 +1,54 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+	"github.com/spf13/pflag"
+)
+
+// filterOption is the options of the request filter, all the requests are matched if there is no filter given
+type filterOption struct {
+	filterPath []string
+	filterFile string
+
+	// inner fields
+	filter filter.Filter
+}
+
+func (o *filterOption) setFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVarP(&o.filterPath, "filter-path", "", []string{}, "The path prefix for filtering")
+	flags.StringVarP(&o.filterFile, "filter-file", "", "",
+		"The YAML file of the filter rules, for example, the hosts, paths, methods and status codes. It is combined with --filter-path")
+}
+
+// validate builds the filter, the requests should match both the path prefixes and the filter file
+func (o *filterOption) validate() (err error) {
+	filters := filter.And{}
+	if len(o.filterPath) > 0 {
+		filters = append(filters, &filter.URLPathFilter{PathPrefix: o.filterPath})
+	}
+	if o.filterFile != "" {
+		var fileFilter filter.Filter
+		if fileFilter, err = filter.ParseFile(o.filterFile); err != nil {
+			return
+		}
+		filters = append(filters, fileFilter)
+	}
+	o.filter = filters
+	return
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"time"
+
+	"github.com/elazarl/goproxy"
+	"github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/rpc"
+	"github.com/linuxsuren/atest-ext-collector/pkg/websocket"
+	"github.com/spf13/pflag"
+)
+
+// grpcOption is the options of the gRPC recording, the plaintext gRPC proxy is disabled if the port is zero
+type grpcOption struct {
+	grpcPort    int
+	grpcTarget  string
+	grpcOutput  string
+	protoFile   string
+	protoImport []string
+	protoSet    string
+}
+
+func (o *grpcOption) setFlags(flags *pflag.FlagSet) {
+	flags.IntVarP(&o.grpcPort, "grpc-port", "", 0,
+		"The port for the plaintext gRPC proxy, it will not be started if it is zero")
+	flags.StringVarP(&o.grpcTarget, "grpc-target", "", "", "The address of the gRPC server, for example: localhost:7070")
+	flags.StringVarP(&o.grpcOutput, "grpc-output", "", "grpc-sample.yaml", "The output file of the gRPC test suite")
+	flags.StringVarP(&o.protoFile, "proto-file", "", "", "The .proto file to decode the gRPC messages")
+	flags.StringSliceVarP(&o.protoImport, "proto-import", "", []string{}, "The import paths of the .proto file")
+	flags.StringVarP(&o.protoSet, "proto-set", "", "",
+		"The descriptor set to decode the gRPC messages, generated by: protoc --include_imports --descriptor_set_out")
+}
+
+func (o *grpcOption) validate() (err error) {
+	if o.grpcPort == 0 {
+		return
+	}
+	if o.grpcTarget == "" {
+		err = fmt.Errorf("--grpc-target is required by the gRPC proxy")
+	} else if o.protoFile == "" && o.protoSet == "" {
+		err = fmt.Errorf("--proto-file or --proto-set is required by the gRPC proxy")
+	}
+	return
+}
+
+func (o *grpcOption) loadDescriptors() (descriptors *rpc.Descriptors, err error) {
+	descriptors = rpc.NewDescriptors()
+	if o.protoFile != "" {
+		err = descriptors.LoadProtoFiles(o.protoImport, o.protoFile)
+	}
+	if err == nil && o.protoSet != "" {
+		err = descriptors.LoadProtoSet(o.protoSet)
+	}
+	return
+}
+
+// hasDescriptors returns true if the gRPC messages could be decoded, then the gRPC
+// calls of the intercepted HTTPS connections are recorded as well
+func (o *grpcOption) hasDescriptors() bool {
+	return o.protoFile != "" || o.protoSet != ""
+}
+
+// newGRPCRecorder creates the collector of the gRPC calls, they are written into the gRPC output file
+func (o *grpcOption) newGRPCRecorder(saveResponseBody bool, headerPolicy *pkg.HeaderPolicy,
+	flushInterval time.Duration) (descriptors *rpc.Descriptors, collects *pkg.Collects, writer *pkg.OutputWriter, err error) {
+	if descriptors, err = o.loadDescriptors(); err != nil {
+		return
+	}
+
+	collects = pkg.NewCollects()
+	exporter := pkg.NewGRPCExporter(o.grpcTarget, &testing.RPCDesc{
+		ImportPath: o.protoImport,
+		ProtoFile:  o.protoFile,
+		ProtoSet:   o.protoSet,
+	}, saveResponseBody).WithHeaderPolicy(headerPolicy)
+	writer = pkg.NewOutputWriter(flushInterval)
+	writer.AddOutput(o.grpcOutput, exporter)
+	collects.AddEvent(exporter.Add)
+	collects.AddEvent(writer.Add)
+	return
+}
+
+// newGRPCServer creates the plaintext gRPC proxy server of the gRPC target, the recorded bodies are
+// limited by the max body size
+func (o *grpcOption) newGRPCServer(descriptors *rpc.Descriptors, add rpc.AddFunc,
+	maxBodySize int64) (srv *http.Server, err error) {
+	var proxy *rpc.Proxy
+	if proxy, err = rpc.NewProxy(o.grpcTarget, descriptors, add); err == nil {
+		srv = &http.Server{
+			Addr:    fmt.Sprintf(":%d", o.grpcPort),
+			Handler: proxy.WithMaxBodySize(maxBodySize).Handler(),
+		}
+	}
+	return
+}
+
+// mitmHandler routes the requests of the intercepted connections, the gRPC calls are recorded by the
+// gRPC proxy, the upgrades are forwarded without recording, and the others go through the HTTP proxy
+type mitmHandler struct {
+	proxy   http.Handler
+	grpc    http.Handler
+	upgrade http.Handler
+}
+
+func newMITMHandler(proxy *goproxy.ProxyHttpServer, grpcProxy *rpc.Proxy) *mitmHandler {
+	return &mitmHandler{
+		proxy: flushEventStream(proxy),
+		grpc:  grpcProxy,
+		upgrade: &httputil.ReverseProxy{
+			// the URLs are absolute already
+			Director:  func(*http.Request) {},
+			Transport: proxy.Tr,
+		},
+	}
+}
+
+func (h *mitmHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case rpc.IsGRPC(r):
+		h.grpc.ServeHTTP(w, r)
+	case websocket.IsUpgrade(r):
+		h.upgrade.ServeHTTP(w, r)
+	default:
+		h.proxy.ServeHTTP(w, r)
+	}
+}
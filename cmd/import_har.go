@@ -0,0 +1,106 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+	"github.com/spf13/cobra"
+)
+
+type importHAROption struct {
+	policyOption
+	filterOption
+	saveResponseBody bool
+	output           string
+}
+
+func createImportHARCmd() (c *cobra.Command) {
+	opt := &importHAROption{}
+	c = &cobra.Command{
+		Use:     "import-har",
+		Short:   "Import a HAR file, which could be exported from the browser devtools, as an API testing suite",
+		Example: "atest-collector import-har recording.har --filter-path /api",
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+		Args:    cobra.ExactArgs(1),
+	}
+	flags := c.Flags()
+	flags.BoolVarP(&opt.saveResponseBody, "save-response-body", "", false, "Save the response body")
+	flags.StringVarP(&opt.output, "output", "o", "sample.yaml", "The output file")
+	opt.policyOption.setFlags(flags)
+	opt.filterOption.setFlags(flags)
+	return
+}
+
+func (o *importHAROption) preRunE(c *cobra.Command, args []string) (err error) {
+	if err = o.policyOption.preRunE(c, args); err == nil {
+		err = o.filterOption.validate()
+	}
+	return
+}
+
+func (o *importHAROption) runE(c *cobra.Command, args []string) (err error) {
+	var har *pkg.HAR
+	if har, err = pkg.ParseHARFile(args[0]); err != nil {
+		return
+	}
+
+	// the entries are deduplicated in the same way as the live capture
+	collects := pkg.NewCollects()
+	collects.SetKeyStrategy(newKeyStrategy(false, false))
+	collects.SetOverflowPolicy(pkg.OverflowBlock)
+	exporter, raw := o.newExporter(func() *pkg.SampleExporter {
+		return o.newSampleExporter(o.saveResponseBody)
+	})
+	if raw {
+		collects.AddRawEvent(exporter.Add)
+	} else {
+		collects.AddEvent(exporter.Add)
+	}
+	defer collects.Stop()
+
+	count := 0
+	for i, entry := range har.Log.Entries {
+		var reqAndResp *pkg.RequestAndResponse
+		if reqAndResp, err = entry.ToRequestAndResponse(); err != nil {
+			err = fmt.Errorf("failed to convert entry %d: %v", i, err)
+			return
+		}
+
+		request := reqAndResp.Request
+		if !o.filter.Match(&filter.Exchange{Method: request.Method, URL: request.URL, StatusCode: reqAndResp.Response.StatusCode}) ||
+			!o.contentPolicy.Match(reqAndResp.Response.Header.Get("Content-Type")) {
+			continue
+		}
+		collects.Add(request, reqAndResp.Response)
+		count++
+	}
+	if err = collects.Wait(c.Context()); err != nil {
+		return
+	}
+
+	var data string
+	if data, err = exporter.Export(); err == nil {
+		if err = os.WriteFile(o.output, []byte(data), 0644); err == nil {
+			c.Printf("%d of %d entries are imported into %s\n", count, len(har.Log.Entries), o.output)
+		}
+	}
+	return
+}
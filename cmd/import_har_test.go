@@ -0,0 +1,94 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "embed"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportHARCmd(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "sample.yaml")
+
+	c := CreateRootCmd()
+	buf := new(bytes.Buffer)
+	c.SetOut(buf)
+	c.SetArgs([]string{"import-har", "../pkg/testdata/sample.har", "--filter-path", "/api",
+		"--save-response-body", "--output", output})
+	assert.NoError(t, c.Execute())
+	assert.Equal(t, "2 of 3 entries are imported into "+output+"\n", buf.String())
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, importHARSuite, string(data), string(data))
+
+	c.SetArgs([]string{"import-har", "fake.har"})
+	assert.Error(t, c.Execute())
+
+	c.SetArgs([]string{"import-har", "../pkg/testdata/sample.har", "--format", "fake"})
+	assert.Error(t, c.Execute())
+}
+
+func TestImportHARDeduplicate(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("../pkg/testdata/sample.har")
+	assert.NoError(t, err)
+	har := &pkg.HAR{}
+	assert.NoError(t, json.Unmarshal(data, har))
+	// the repeated calls are recorded once like the live capture
+	har.Log.Entries = append(har.Log.Entries, har.Log.Entries...)
+	data, err = json.Marshal(har)
+	assert.NoError(t, err)
+	input, output := filepath.Join(dir, "repeated.har"), filepath.Join(dir, "sample.yaml")
+	assert.NoError(t, os.WriteFile(input, data, 0644))
+
+	c := CreateRootCmd()
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{"import-har", input, "--filter-path", "/api", "--save-response-body", "--output", output})
+	assert.NoError(t, c.Execute())
+
+	data, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, importHARSuite, string(data), string(data))
+}
+
+func TestImportHARAsOpenAPI(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "openapi.yaml")
+
+	c := CreateRootCmd()
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{"import-har", "../pkg/testdata/sample.har", "--content-type", "*/*",
+		"--format", "openapi", "--output", output})
+	assert.NoError(t, c.Execute())
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, importHAROpenAPI, string(data), string(data))
+}
+
+//go:embed testdata/import_har_openapi.yaml
+var importHAROpenAPI string
+
+//go:embed testdata/import_har_suite.yaml
+var importHARSuite string
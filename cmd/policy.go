@@ -0,0 +1,176 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	formatAPITesting = "api-testing"
+	formatOpenAPI    = "openapi"
+	formatPostman    = "postman"
+	formatK6         = "k6"
+)
+
+var supportedFormats = []string{formatAPITesting, formatOpenAPI, formatPostman, formatK6}
+
+// policyOption is the options of the content type and header policies
+type policyOption struct {
+	config       string
+	contentTypes []string
+	excludeTypes []string
+	binaryMode   string
+	dropHeaders  []string
+	keepHeaders  []string
+	maskHeaders  []string
+	secretMode   string
+	assertions   bool
+	chain        bool
+	format       string
+	k6Weighted   bool
+	graphQLError bool
+
+	// inner fields
+	contentPolicy *pkg.ContentTypePolicy
+	headerPolicy  *pkg.HeaderPolicy
+}
+
+func (o *policyOption) setFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.config, "config", "", "", "The config file of the collector")
+	flags.StringSliceVarP(&o.contentTypes, "content-type", "", pkg.DefaultContentTypes,
+		"The content type patterns of the responses to capture, for example: text/*")
+	flags.StringSliceVarP(&o.excludeTypes, "exclude-content-type", "", []string{},
+		"The content type patterns of the responses to ignore")
+	flags.StringVarP(&o.binaryMode, "binary-body", "", string(pkg.BinaryModeSummary),
+		"The way to keep the binary bodies, supported: summary, base64")
+	flags.StringSliceVarP(&o.dropHeaders, "drop-header", "", []string{},
+		"The request header patterns to drop besides the hop-by-hop and noisy ones")
+	flags.StringSliceVarP(&o.keepHeaders, "keep-header", "", []string{},
+		"The request header patterns to keep even if they are the hop-by-hop or noisy ones")
+	flags.StringSliceVarP(&o.maskHeaders, "mask-header", "", []string{},
+		"The request header patterns to mask besides Authorization, Cookie and API keys")
+	flags.StringVarP(&o.secretMode, "secret-mode", "", string(pkg.SecretModeTemplate),
+		"The way to keep the secret headers, supported: template, mask, keep")
+	flags.BoolVarP(&o.assertions, "generate-assertions", "", false,
+		"Generate the field assertions of the JSON response bodies instead of the exact matches")
+	flags.StringVarP(&o.format, "format", "", formatAPITesting,
+		fmt.Sprintf("The format of the output file, supported: %s", strings.Join(supportedFormats, ", ")))
+	flags.BoolVarP(&o.k6Weighted, "k6-weighted", "", false,
+		"Pick the requests of the k6 script randomly by the observed frequency instead of replaying the recording")
+	flags.BoolVarP(&o.chain, "detect-chain", "", false,
+		"Replace the tokens and IDs of the earlier responses in the later requests with the template references")
+	flags.BoolVarP(&o.graphQLError, "fail-on-graphql-errors", "", false,
+		"Expect no errors in the GraphQL responses, then the test cases fail if the errors are returned with HTTP 200")
+}
+
+func (o *policyOption) preRunE(cmd *cobra.Command, args []string) (err error) {
+	if o.format == "" {
+		o.format = formatAPITesting
+	}
+	if !contains(supportedFormats, o.format) {
+		err = fmt.Errorf("unsupported format %q, supported: %s", o.format, strings.Join(supportedFormats, ", "))
+		return
+	}
+
+	o.contentPolicy = &pkg.ContentTypePolicy{}
+	o.headerPolicy = &pkg.HeaderPolicy{}
+	if o.config != "" {
+		var config *pkg.CollectorConfig
+		if config, err = pkg.ParseCollectorConfig(o.config); err != nil {
+			return
+		}
+		if config.ContentType != nil {
+			o.contentPolicy = config.ContentType
+		}
+		if config.Header != nil {
+			o.headerPolicy = config.Header
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("content-type") || o.contentPolicy.Allow == nil {
+		o.contentPolicy.Allow = o.contentTypes
+	}
+	if flags.Changed("exclude-content-type") || o.contentPolicy.Deny == nil {
+		o.contentPolicy.Deny = o.excludeTypes
+	}
+	if flags.Changed("binary-body") || o.contentPolicy.Binary == "" {
+		o.contentPolicy.Binary = pkg.BinaryMode(o.binaryMode)
+	}
+	if err = o.contentPolicy.Validate(); err != nil {
+		return
+	}
+
+	// the config and flags extend the default headers, the kept ones are not dropped
+	defaultHeaderPolicy := pkg.NewHeaderPolicy()
+	o.headerPolicy.Drop = append(append(defaultHeaderPolicy.Drop, o.headerPolicy.Drop...), o.dropHeaders...)
+	o.headerPolicy.Keep = append(o.headerPolicy.Keep, o.keepHeaders...)
+	o.headerPolicy.Mask = append(append(defaultHeaderPolicy.Mask, o.headerPolicy.Mask...), o.maskHeaders...)
+	if flags.Changed("secret-mode") || o.headerPolicy.Mode == "" {
+		o.headerPolicy.Mode = pkg.SecretMode(o.secretMode)
+	}
+	err = o.headerPolicy.Validate()
+	return
+}
+
+func (o *policyOption) newSampleExporter(saveResponseBody bool) *pkg.SampleExporter {
+	return pkg.NewSampleExporter(saveResponseBody).
+		WithBinaryMode(o.contentPolicy.Binary).
+		WithHeaderPolicy(o.headerPolicy).
+		WithAssertions(o.assertions).
+		WithChain(o.chain).
+		WithGraphQLErrors(o.graphQLError)
+}
+
+func (o *policyOption) newPostmanExporter() *pkg.PostmanExporter {
+	return pkg.NewPostmanExporter("sample").WithHeaderPolicy(o.headerPolicy)
+}
+
+func (o *policyOption) newK6Exporter() *pkg.K6Exporter {
+	return pkg.NewK6Exporter().WithHeaderPolicy(o.headerPolicy).WithWeighted(o.k6Weighted)
+}
+
+// newExporter creates the exporter of the output format, the test suite is created by newSample. The raw
+// exporters should receive all the requests, including the duplicated ones
+func (o *policyOption) newExporter(newSample func() *pkg.SampleExporter) (exporter pkg.Exporter, raw bool) {
+	switch o.format {
+	case formatOpenAPI:
+		exporter, raw = pkg.NewOpenAPIExporter("Collected APIs"), true
+	case formatPostman:
+		exporter, raw = o.newPostmanExporter(), true
+	case formatK6:
+		exporter, raw = o.newK6Exporter(), true
+	default:
+		exporter = newSample()
+	}
+	return
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
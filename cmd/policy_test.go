@@ -0,0 +1,112 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyOption(t *testing.T) {
+	c := createCollectorCmd()
+	opt := &policyOption{contentTypes: pkg.DefaultContentTypes, binaryMode: "base64"}
+	assert.NoError(t, opt.preRunE(c, nil))
+	assert.Equal(t, pkg.DefaultContentTypes, opt.contentPolicy.Allow)
+	assert.Equal(t, pkg.BinaryModeBase64, opt.contentPolicy.Binary)
+	assert.Equal(t, pkg.DefaultMaskHeaders, opt.headerPolicy.Mask)
+
+	opt = &policyOption{config: "../pkg/testdata/collector_config.yaml"}
+	assert.NoError(t, opt.preRunE(c, nil))
+	assert.Equal(t, []string{"text/html"}, opt.contentPolicy.Deny)
+	assert.Equal(t, pkg.BinaryModeBase64, opt.contentPolicy.Binary)
+	assert.Equal(t, pkg.SecretModeMask, opt.headerPolicy.Mode)
+	assert.Equal(t, append(append([]string{}, pkg.DefaultMaskHeaders...), "X-Tenant-Secret"), opt.headerPolicy.Mask)
+	assert.Equal(t, append(append([]string{}, pkg.DefaultDropHeaders...), "User-Agent"), opt.headerPolicy.Drop)
+	// the hop-by-hop headers are dropped even if the config drops the others
+	assert.Equal(t, map[string]string{"Accept": "*/*"}, opt.headerPolicy.Apply(http.Header{
+		"Accept": {"*/*"}, "Connection": {"keep-alive"}, "Host": {"foo"}, "User-Agent": {"curl"},
+	}))
+
+	opt = &policyOption{config: "../pkg/testdata/collector_config.yaml", keepHeaders: []string{"Host"}}
+	assert.NoError(t, opt.preRunE(c, nil))
+	assert.Equal(t, map[string]string{"Host": "foo"}, opt.headerPolicy.Apply(http.Header{"Host": {"foo"}, "Connection": {"close"}}))
+
+	opt = &policyOption{config: "fake.yaml"}
+	assert.Error(t, opt.preRunE(c, nil))
+
+	opt = &policyOption{binaryMode: "fake"}
+	assert.Error(t, opt.preRunE(c, nil))
+
+	opt = &policyOption{secretMode: "fake"}
+	assert.Error(t, opt.preRunE(c, nil))
+}
+
+func TestGRPCOption(t *testing.T) {
+	opt := &grpcOption{}
+	assert.NoError(t, opt.validate())
+
+	opt = &grpcOption{grpcPort: 9090}
+	assert.Error(t, opt.validate())
+
+	opt.grpcTarget = "localhost:7070"
+	assert.Error(t, opt.validate())
+
+	opt.protoFile = "hello.proto"
+	opt.protoImport = []string{"../pkg/rpc/testdata"}
+	assert.NoError(t, opt.validate())
+
+	assert.True(t, opt.hasDescriptors())
+
+	descriptors, collects, writer, err := opt.newGRPCRecorder(false, pkg.NewHeaderPolicy(), 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, collects)
+	assert.NotNil(t, writer)
+	collects.Stop()
+
+	srv, err := opt.newGRPCServer(descriptors, collects.Add, pkg.DefaultMaxBodySize)
+	assert.NoError(t, err)
+	assert.Equal(t, ":9090", srv.Addr)
+
+	opt.protoFile = "fake.proto"
+	_, _, _, err = opt.newGRPCRecorder(false, pkg.NewHeaderPolicy(), 0)
+	assert.Error(t, err)
+}
+
+func TestMITMHandler(t *testing.T) {
+	named := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name))
+		})
+	}
+	handler := &mitmHandler{proxy: named("proxy"), grpc: named("grpc"), upgrade: named("upgrade")}
+	serve := func(header http.Header) string {
+		req := httptest.NewRequest(http.MethodPost, "https://foo.com/sample.Greeter/SayHello", nil)
+		req.Header = header
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Body.String()
+	}
+
+	assert.Equal(t, "grpc", serve(http.Header{"Content-Type": {rpc.ContentType + "+proto"}}))
+	assert.Equal(t, "upgrade", serve(http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}}))
+	assert.Equal(t, "proxy", serve(http.Header{"Content-Type": {"application/json"}}))
+}
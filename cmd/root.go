@@ -28,6 +28,6 @@ func CreateRootCmd() (c *cobra.Command) {
 	}
 
 	c.AddCommand(createCollectorCmd(), createControllerCmd(), createServiceCommand(exec.FakeExecer{}),
-		createProxyCmd(), createDNSCmd())
+		createProxyCmd(), createDNSCmd(), createCACmd(), createImportHARCmd())
 	return
 }
@@ -0,0 +1,92 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/sink"
+	"github.com/spf13/pflag"
+)
+
+// sinkOption is the options of the extra sinks, every session could be fanned out to several of them
+type sinkOption struct {
+	sink  []string
+	specs []sink.Spec
+}
+
+func (o *sinkOption) setFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVarP(&o.sink, "sink", "", []string{},
+		"The extra sinks of the collected requests as name:target, supported: "+
+			"file:<suite file>, stdout, webhook:<URL>, dir:<directory>, atest:<server address>?suite=<name>&store=<name>&retry=<times>")
+}
+
+func (o *sinkOption) validate() (err error) {
+	o.specs = nil
+	for _, text := range o.sink {
+		var spec sink.Spec
+		if spec, err = sink.ParseSpec(text); err != nil {
+			return
+		}
+		o.specs = append(o.specs, spec)
+	}
+	return
+}
+
+// openSinks opens the file sink of the output and the extra sinks, every sink handles the requests in its own
+// goroutine. The returned queues should be closed after stopping the collector.
+func (o *sinkOption) openSinks(collects *pkg.Collects, output, config sink.Config) (queues []*sink.Queue, err error) {
+	var raw []bool
+	open := func(spec sink.Spec, config sink.Config) (err error) {
+		var s sink.Sink
+		if s, err = sink.Open(spec, config); err == nil {
+			_, ok := s.(sink.RawSink)
+			queues, raw = append(queues, sink.NewQueue(spec.Name, s, 0)), append(raw, ok)
+		}
+		return
+	}
+
+	if err = open(sink.Spec{Name: "file", Target: output.Target}, output); err == nil {
+		for _, spec := range o.specs {
+			if err = open(spec, config); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = closeSinks(queues)
+		queues = nil
+		return
+	}
+
+	// the events are added after all the sinks are opened, then the closed ones are never called
+	for i, queue := range queues {
+		if raw[i] {
+			collects.AddRawEvent(queue.HandleRaw)
+		}
+		collects.AddEvent(queue.Handle)
+	}
+	return
+}
+
+func closeSinks(queues []*sink.Queue) (err error) {
+	for _, queue := range queues {
+		if closeErr := queue.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// flushEventStream flushes the Server-Sent Events to the clients as soon as they are forwarded. goproxy flushes
+// only if the content type is exactly text/event-stream, then the ones with parameters were buffered, like charset.
+func flushEventStream(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&eventStreamWriter{ResponseWriter: w}, r)
+	})
+}
+
+// eventStreamWriter flushes after each write if the response is an event stream,
+// the connection could be hijacked as well, like the CONNECT and WebSocket requests
+type eventStreamWriter struct {
+	http.ResponseWriter
+	stream bool
+}
+
+func (w *eventStreamWriter) WriteHeader(statusCode int) {
+	w.stream = pkg.IsEventStream(w.Header().Get("Content-Type"))
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *eventStreamWriter) Write(data []byte) (n int, err error) {
+	if n, err = w.ResponseWriter.Write(data); err == nil && w.stream {
+		w.Flush()
+	}
+	return
+}
+
+func (w *eventStreamWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *eventStreamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("the connection could not be hijacked")
+}
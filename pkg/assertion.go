@@ -0,0 +1,174 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Assertions are the checks of a JSON response body, they are the
+// bodyFieldsExpect and verify of api-testing
+type Assertions struct {
+	// BodyFieldsExpect are the stable values, the keys are gjson paths
+	BodyFieldsExpect map[string]interface{}
+	// Verify are the expressions which check the field presence, types and array lengths
+	Verify []string
+}
+
+// DefaultAssertionDepth is the max depth of the nested fields to generate assertions
+const DefaultAssertionDepth = 5
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	timePattern       = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}|$)`)
+	tokenPattern      = regexp.MustCompile(`^[A-Za-z0-9_\-+/=.]{32,}$`)
+	volatileKeys      = []string{"id", "uuid", "guid", "token", "nonce", "time", "timestamp",
+		"date", "expires", "expiry", "created", "updated", "modified", "signature", "session", "etag", "trace"}
+)
+
+// GenerateAssertions analyzes the JSON body and generates the assertions instead of an exact match.
+// The volatile values, like timestamps, identifiers and tokens, are checked by their types only.
+func GenerateAssertions(body string) (assertions *Assertions, err error) {
+	var data interface{}
+	if err = json.Unmarshal([]byte(body), &data); err != nil {
+		return
+	}
+
+	assertions = &Assertions{BodyFieldsExpect: map[string]interface{}{}}
+	assertions.walk(nil, "data", "", data, true, 0)
+	return
+}
+
+func (a *Assertions) walk(fieldPath []string, expr, key string, value interface{}, stable bool, depth int) {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		if depth > 0 {
+			a.Verify = append(a.Verify, fmt.Sprintf(`type(%s) == "map"`, expr))
+		}
+		if depth >= DefaultAssertionDepth {
+			return
+		}
+
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			a.walk(append(fieldPath, escapeFieldPath(k)), expr+exprField(k), k, val[k], stable, depth+1)
+		}
+	case []interface{}:
+		a.Verify = append(a.Verify, fmt.Sprintf("len(%s) == %d", expr, len(val)))
+		// only the first item is checked, and the values of items are taken as unstable
+		if len(val) > 0 && depth < DefaultAssertionDepth {
+			a.walk(append(fieldPath, "0"), expr+"[0]", key, val[0], false, depth+1)
+		}
+	case nil:
+		a.Verify = append(a.Verify, fmt.Sprintf("%s == nil", expr))
+	case bool:
+		if stable && len(fieldPath) > 0 {
+			a.BodyFieldsExpect[strings.Join(fieldPath, ".")] = val
+		} else {
+			a.Verify = append(a.Verify, fmt.Sprintf(`type(%s) == "bool"`, expr))
+		}
+	case float64:
+		if stable && len(fieldPath) > 0 && !isVolatileKey(key) && !isTimestampNumber(val) {
+			a.BodyFieldsExpect[strings.Join(fieldPath, ".")] = val
+		} else {
+			a.Verify = append(a.Verify, fmt.Sprintf(`type(%s) in ["int", "float"]`, expr))
+		}
+	case string:
+		if stable && len(fieldPath) > 0 && !isVolatileKey(key) && !isVolatileValue(val) {
+			a.BodyFieldsExpect[strings.Join(fieldPath, ".")] = val
+		} else {
+			a.Verify = append(a.Verify, fmt.Sprintf(`type(%s) == "string"`, expr))
+		}
+	}
+}
+
+// isVolatileKey returns true if the field name looks like an identifier or a time,
+// for example: id, userId, user_id, createdAt, expires_in
+func isVolatileKey(key string) bool {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+	var parts []string
+	for _, word := range words {
+		parts = append(parts, splitCamelCase(word)...)
+	}
+	for i, part := range parts {
+		part = strings.ToLower(part)
+		for _, volatile := range volatileKeys {
+			if part == volatile || part == volatile+"s" || part == volatile+"d" {
+				return true
+			}
+		}
+		// the suffix of createdAt, started_at
+		if part == "at" && i > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isVolatileValue returns true if the value looks like a time, an identifier or a token
+func isVolatileValue(value string) bool {
+	return timePattern.MatchString(value) || IsPathParam(value) || tokenPattern.MatchString(value)
+}
+
+// isTimestampNumber returns true if the number looks like a Unix timestamp in seconds or milliseconds
+func isTimestampNumber(value float64) bool {
+	return value >= 1e9 && value < 1e10 || value >= 1e12 && value < 1e13
+}
+
+func splitCamelCase(word string) (parts []string) {
+	start := 0
+	for i := 1; i < len(word); i++ {
+		if word[i] >= 'A' && word[i] <= 'Z' && !(word[i-1] >= 'A' && word[i-1] <= 'Z') {
+			parts = append(parts, word[start:i])
+			start = i
+		}
+	}
+	parts = append(parts, word[start:])
+	return
+}
+
+// escapeFieldPath escapes the special characters of a gjson path component
+func escapeFieldPath(key string) string {
+	var builder strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\':
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// exprField returns the member access of an expr expression
+func exprField(key string) string {
+	if identifierPattern.MatchString(key) {
+		return "." + key
+	}
+	return "[" + strconv.Quote(key) + "]"
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAssertions(t *testing.T) {
+	assertions, err := pkg.GenerateAssertions(`{
+		"id": 1024,
+		"name": "rick",
+		"enabled": true,
+		"score": 9.5,
+		"createdAt": "2026-10-18T08:00:00Z",
+		"token": "eyJhbGciOiJIUzI1NiJ9eyJzdWIiOiIxMjM0NTY3ODkwIn0",
+		"a.b": "dot",
+		"profile": {"email": "rick@example.com", "lastLogin": 1760774400},
+		"items": [{"name": "foo", "price": 10}],
+		"tags": [],
+		"extra": null
+	}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":          "rick",
+		"enabled":       true,
+		"score":         9.5,
+		`a\.b`:          "dot",
+		"profile.email": "rick@example.com",
+	}, assertions.BodyFieldsExpect)
+	assert.Equal(t, []string{
+		`type(data.createdAt) == "string"`,
+		"data.extra == nil",
+		`type(data.id) in ["int", "float"]`,
+		"len(data.items) == 1",
+		`type(data.items[0]) == "map"`,
+		`type(data.items[0].name) == "string"`,
+		`type(data.items[0].price) in ["int", "float"]`,
+		`type(data.profile) == "map"`,
+		`type(data.profile.lastLogin) in ["int", "float"]`,
+		"len(data.tags) == 0",
+		`type(data.token) == "string"`,
+	}, assertions.Verify)
+
+	assertions, err = pkg.GenerateAssertions(`[1, 2]`)
+	assert.NoError(t, err)
+	assert.Empty(t, assertions.BodyFieldsExpect)
+	assert.Equal(t, []string{"len(data) == 2", `type(data[0]) in ["int", "float"]`}, assertions.Verify)
+
+	_, err = pkg.GenerateAssertions("hello")
+	assert.Error(t, err)
+}
+
+func TestSampleExporterWithAssertions(t *testing.T) {
+	exporter := pkg.NewSampleExporter(true).WithAssertions(true)
+	for _, resp := range []*pkg.SimpleResponse{{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       `{"name": "rick", "userId": 1}`,
+	}, {
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/plain"}},
+		Body:       "hello",
+	}} {
+		request, _ := http.NewRequest(http.MethodGet, "http://foo/api/v1", bytes.NewBufferString(""))
+		exporter.Add(&pkg.RequestAndResponse{Request: request, Response: resp})
+	}
+
+	items := exporter.TestSuite.Items
+	assert.Len(t, items, 2)
+	assert.Empty(t, items[0].Expect.Body)
+	assert.Equal(t, map[string]interface{}{"name": "rick"}, items[0].Expect.BodyFieldsExpect)
+	assert.Equal(t, []string{`type(data.userId) in ["int", "float"]`}, items[0].Expect.Verify)
+	assert.Equal(t, "hello", items[1].Expect.Body)
+	assert.Empty(t, items[1].Expect.Verify)
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elazarl/goproxy"
+)
+
+// DefaultCommonName is the common name of the generated CA
+const DefaultCommonName = "atest-collector CA"
+
+// leafValidity is the validity of the per-host certificates
+const leafValidity = 365 * 24 * time.Hour
+
+// Authority is a local certificate authority which mints the leaf
+// certificates for the intercepted hosts
+type Authority struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+
+	lock  sync.Mutex
+	cache map[string]*tls.Certificate
+}
+
+// Generate creates a new self-signed CA
+func Generate(commonName string, validity time.Duration) (authority *Authority, err error) {
+	var key *ecdsa.PrivateKey
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return
+	}
+
+	var serial *big.Int
+	if serial, err = newSerialNumber(); err != nil {
+		return
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"atest-collector"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	var der []byte
+	if der, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key); err != nil {
+		return
+	}
+
+	var keyDER []byte
+	if keyDER, err = x509.MarshalECPrivateKey(key); err != nil {
+		return
+	}
+
+	authority, err = Parse(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return
+}
+
+// Load reads the CA from the certificate and key files
+func Load(certFile, keyFile string) (authority *Authority, err error) {
+	var certPEM, keyPEM []byte
+	if certPEM, err = os.ReadFile(certFile); err != nil {
+		return
+	}
+	if keyPEM, err = os.ReadFile(keyFile); err != nil {
+		return
+	}
+	authority, err = Parse(certPEM, keyPEM)
+	return
+}
+
+// Parse parses the CA from the PEM encoded certificate and key
+func Parse(certPEM, keyPEM []byte) (authority *Authority, err error) {
+	var pair tls.Certificate
+	if pair, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return
+	}
+
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		err = errors.New("only ECDSA private key is supported")
+		return
+	}
+
+	var cert *x509.Certificate
+	if cert, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+		return
+	}
+	if !cert.IsCA {
+		err = fmt.Errorf("%q is not a CA certificate", cert.Subject.CommonName)
+		return
+	}
+
+	authority = &Authority{
+		cert:    cert,
+		key:     key,
+		certPEM: certPEM,
+		keyPEM:  keyPEM,
+		cache:   make(map[string]*tls.Certificate),
+	}
+	return
+}
+
+// Save writes the certificate and key into files
+func (a *Authority) Save(certFile, keyFile string) (err error) {
+	for _, file := range []string{certFile, keyFile} {
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return
+		}
+	}
+
+	if err = os.WriteFile(certFile, a.certPEM, 0644); err == nil {
+		err = os.WriteFile(keyFile, a.keyPEM, 0600)
+	}
+	return
+}
+
+// CertificatePEM returns the PEM encoded CA certificate
+func (a *Authority) CertificatePEM() []byte {
+	return a.certPEM
+}
+
+// CertificateDER returns the DER encoded CA certificate
+func (a *Authority) CertificateDER() []byte {
+	return a.cert.Raw
+}
+
+// Certificate returns the CA certificate
+func (a *Authority) Certificate() *x509.Certificate {
+	return a.cert
+}
+
+// Sign returns a leaf certificate for the given host, the certificates are cached per host
+func (a *Authority) Sign(host string) (cert *tls.Certificate, err error) {
+	host = stripPort(host)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if cert = a.cache[host]; cert != nil && time.Now().Before(cert.Leaf.NotAfter) {
+		return
+	}
+
+	var key *ecdsa.PrivateKey
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return
+	}
+
+	var serial *big.Int
+	if serial, err = newSerialNumber(); err != nil {
+		return
+	}
+
+	now := time.Now()
+	notAfter := now.Add(leafValidity)
+	if notAfter.After(a.cert.NotAfter) {
+		notAfter = a.cert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host, Organization: []string{"atest-collector"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	var der []byte
+	if der, err = x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key); err != nil {
+		return
+	}
+
+	var leaf *x509.Certificate
+	if leaf, err = x509.ParseCertificate(der); err != nil {
+		return
+	}
+
+	cert = &tls.Certificate{
+		Certificate: [][]byte{der, a.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	a.cache[host] = cert
+	return
+}
+
+// TLSConfig is the TLS config provider of goproxy.ConnectAction
+func (a *Authority) TLSConfig(host string, ctx *goproxy.ProxyCtx) (config *tls.Config, err error) {
+	var cert *tls.Certificate
+	if cert, err = a.Sign(host); err == nil {
+		config = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*cert},
+			// goproxy reads HTTP/1.x only from the intercepted connection
+			NextProtos: []string{"http/1.1"},
+		}
+	}
+	return
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the CA certificate
+func (a *Authority) Fingerprint() string {
+	sum := sha256.Sum256(a.cert.Raw)
+	return fmt.Sprintf("%X", sum[:])
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
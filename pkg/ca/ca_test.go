@@ -0,0 +1,148 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elazarl/goproxy"
+	"github.com/linuxsuren/atest-ext-collector/pkg/ca"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthority(t *testing.T) {
+	authority, err := ca.Generate(ca.DefaultCommonName, time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, authority.Certificate().IsCA)
+	assert.Equal(t, ca.DefaultCommonName, authority.Certificate().Subject.CommonName)
+	assert.Len(t, authority.Fingerprint(), 64)
+
+	t.Run("sign", func(t *testing.T) {
+		cert, err := authority.Sign("foo.com:443")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"foo.com"}, cert.Leaf.DNSNames)
+		assert.False(t, cert.Leaf.NotAfter.After(authority.Certificate().NotAfter))
+
+		pool := x509.NewCertPool()
+		pool.AddCert(authority.Certificate())
+		_, err = cert.Leaf.Verify(x509.VerifyOptions{DNSName: "foo.com", Roots: pool})
+		assert.NoError(t, err)
+
+		cached, err := authority.Sign("foo.com")
+		assert.NoError(t, err)
+		assert.Same(t, cert, cached)
+
+		ipCert, err := authority.Sign("127.0.0.1")
+		assert.NoError(t, err)
+		assert.Len(t, ipCert.Leaf.IPAddresses, 1)
+	})
+
+	t.Run("tls config", func(t *testing.T) {
+		config, err := authority.TLSConfig("bar.com:443", nil)
+		assert.NoError(t, err)
+		assert.Len(t, config.Certificates, 1)
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := filepath.Join(dir, "ca", "ca.crt"), filepath.Join(dir, "ca", "ca.key")
+		assert.NoError(t, authority.Save(certFile, keyFile))
+
+		loaded, err := ca.Load(certFile, keyFile)
+		assert.NoError(t, err)
+		assert.Equal(t, authority.Fingerprint(), loaded.Fingerprint())
+		assert.Equal(t, authority.CertificateDER(), loaded.CertificateDER())
+
+		_, err = ca.Load(filepath.Join(dir, "fake.crt"), keyFile)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ca.Parse([]byte("fake"), []byte("fake"))
+		assert.Error(t, err)
+	})
+}
+
+func TestInterceptor(t *testing.T) {
+	authority, err := ca.Generate(ca.DefaultCommonName, time.Hour)
+	assert.NoError(t, err)
+
+	_, err = ca.NewInterceptor(authority, []string{"("})
+	assert.Error(t, err)
+
+	interceptor, err := ca.NewInterceptor(authority, nil)
+	assert.NoError(t, err)
+	assert.True(t, interceptor.ShouldIntercept("foo.com:443"))
+
+	interceptor, err = ca.NewInterceptor(authority, []string{`^api\.foo\.com$`})
+	assert.NoError(t, err)
+	assert.True(t, interceptor.ShouldIntercept("api.foo.com:443"))
+	assert.False(t, interceptor.ShouldIntercept("www.foo.com:443"))
+
+	action, host := interceptor.ConnectFilter("api.foo.com:443", nil)
+	assert.EqualValues(t, goproxy.ConnectMitm, action.Action)
+	assert.Equal(t, "api.foo.com:443", host)
+
+	action, _ = interceptor.ConnectFilter("www.foo.com:443", nil)
+	assert.Equal(t, goproxy.OkConnect, action)
+}
+
+func TestInterceptorHTTP2(t *testing.T) {
+	authority, err := ca.Generate(ca.DefaultCommonName, time.Hour)
+	assert.NoError(t, err)
+	interceptor, err := ca.NewInterceptor(authority, nil)
+	assert.NoError(t, err)
+	interceptor.WithHTTP2(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s %s", r.Proto, r.URL)
+	}))
+
+	proxy := goproxy.NewProxyHttpServer()
+	proxy.OnRequest().HandleConnectFunc(interceptor.ConnectFilter)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+	proxyURL, _ := url.Parse(server.URL)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(authority.Certificate())
+	get := func(transport *http.Transport) string {
+		transport.Proxy = http.ProxyURL(proxyURL)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		defer transport.CloseIdleConnections()
+
+		resp, err := (&http.Client{Transport: transport}).Get("https://foo.com/api/users")
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	assert.Equal(t, "HTTP/2.0 https://foo.com/api/users", get(&http.Transport{ForceAttemptHTTP2: true}))
+	assert.Equal(t, "HTTP/1.1 https://foo.com/api/users", get(&http.Transport{}))
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/elazarl/goproxy"
+	"golang.org/x/net/http2"
+)
+
+// Interceptor decides which CONNECT tunnels will be intercepted,
+// all the other hosts keep being tunneled as-is
+type Interceptor struct {
+	authority *Authority
+	hosts     []*regexp.Regexp
+	handler   http.Handler
+}
+
+// NewInterceptor creates an interceptor, it intercepts all hosts if no patterns are given
+func NewInterceptor(authority *Authority, hostPatterns []string) (interceptor *Interceptor, err error) {
+	interceptor = &Interceptor{authority: authority}
+	for _, pattern := range hostPatterns {
+		var reg *regexp.Regexp
+		if reg, err = regexp.Compile(pattern); err != nil {
+			err = fmt.Errorf("find wrong pattern: %q, error is: %v", pattern, err)
+			return
+		}
+		interceptor.hosts = append(interceptor.hosts, reg)
+	}
+	return
+}
+
+// ShouldIntercept returns true if the host needs to be intercepted
+func (i *Interceptor) ShouldIntercept(host string) bool {
+	if len(i.hosts) == 0 {
+		return true
+	}
+
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	for _, reg := range i.hosts {
+		if reg.MatchString(hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithHTTP2 serves the intercepted connections by the handler, then HTTP/2 is negotiated as well as
+// HTTP/1.1, goproxy reads HTTP/1.1 only. The requests have the absolute https URLs.
+func (i *Interceptor) WithHTTP2(handler http.Handler) *Interceptor {
+	i.handler = handler
+	return i
+}
+
+// ConnectFilter is the goproxy CONNECT handler
+func (i *Interceptor) ConnectFilter(host string, ctx *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
+	if !i.ShouldIntercept(host) {
+		return goproxy.OkConnect, host
+	}
+
+	log.Printf("intercept: %q\n", host)
+	if i.handler != nil {
+		return &goproxy.ConnectAction{
+			Action: goproxy.ConnectHijack,
+			Hijack: i.hijack,
+		}, host
+	}
+	return &goproxy.ConnectAction{
+		Action:    goproxy.ConnectMitm,
+		TLSConfig: i.authority.TLSConfig,
+	}, host
+}
+
+// hijack replies the CONNECT request, then serves the intercepted connection by the handler
+func (i *Interceptor) hijack(req *http.Request, client net.Conn, ctx *goproxy.ProxyCtx) {
+	defer func() {
+		_ = client.Close()
+	}()
+
+	host := req.URL.Host
+	config, err := i.authority.TLSConfig(host, ctx)
+	if err == nil {
+		_, err = client.Write([]byte("HTTP/1.0 200 OK\r\n\r\n"))
+	}
+	if err != nil {
+		log.Println("failed to intercept", host, err)
+		return
+	}
+
+	config.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+	conn := tls.Server(client, config)
+	if err = conn.Handshake(); err != nil {
+		log.Println("failed to intercept", host, err)
+		return
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Scheme = "https"
+		if r.URL.Host = r.Host; r.URL.Host == "" {
+			r.URL.Host = host
+		}
+		i.handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), interceptedKey{}, true)))
+	})
+	if conn.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS {
+		(&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+	} else {
+		_ = http.Serve(newConnListener(conn), handler)
+	}
+}
+
+type interceptedKey struct{}
+
+// IsIntercepted returns true if the request is read from a hijacked connection,
+// the CONNECT request of the connection has passed the earlier CONNECT handlers
+func IsIntercepted(r *http.Request) bool {
+	intercepted, _ := r.Context().Value(interceptedKey{}).(bool)
+	return intercepted
+}
+
+// connListener accepts only one connection, it is closed with the connection
+type connListener struct {
+	conn net.Conn
+	once sync.Once
+	done chan struct{}
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	l := &connListener{done: make(chan struct{})}
+	l.conn = &listenedConn{Conn: conn, listener: l}
+	return l
+}
+
+func (l *connListener) Accept() (conn net.Conn, err error) {
+	conn, l.conn = l.conn, nil
+	if conn == nil {
+		<-l.done
+		err = net.ErrClosed
+	}
+	return
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+type listenedConn struct {
+	net.Conn
+	listener *connListener
+}
+
+func (c *listenedConn) Close() error {
+	_ = c.listener.Close()
+	return c.Conn.Close()
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// minChainValueLength is the min length of the values which could be found inside of other text, like tokens
+	minChainValueLength = 8
+	// minExactChainValueLength is the min length of the values which must match exactly, like numeric IDs
+	minExactChainValueLength = 3
+)
+
+// ChainDetector tracks the identifiers and tokens of the earlier responses, then replaces
+// them in the later requests with the api-testing template references, for example:
+// the token of the login response becomes {{.login.token}} in the later headers
+type ChainDetector struct {
+	values map[string]chainValue
+	// sorted is the cache of sortedValues, it is reset when a value is tracked
+	sorted []chainValue
+}
+
+type chainValue struct {
+	value string
+	// ref is the template reference to the case output
+	ref string
+	// exact means it could only be replaced when the whole value matches
+	exact bool
+	// field matches the exact value as a whole JSON value, it is compiled once when tracking
+	field *regexp.Regexp
+}
+
+// NewChainDetector creates a chain detector
+func NewChainDetector() *ChainDetector {
+	return &ChainDetector{values: map[string]chainValue{}}
+}
+
+// Harvest tracks the values of a JSON response body, the later ones take precedence
+func (d *ChainDetector) Harvest(caseName, body string) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(body), &data); err == nil {
+		d.harvest([]interface{}{caseName}, "", data, 0)
+	}
+}
+
+func (d *ChainDetector) harvest(path []interface{}, key string, value interface{}, depth int) {
+	if depth > DefaultAssertionDepth {
+		return
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			d.harvest(append(path[:len(path):len(path)], k), k, val[k], depth+1)
+		}
+	case []interface{}:
+		for i, item := range val {
+			d.harvest(append(path[:len(path):len(path)], i), key, item, depth+1)
+		}
+	case string:
+		if timePattern.MatchString(val) {
+			return
+		}
+		if len(val) >= minChainValueLength && (isVolatileKey(key) || isVolatileValue(val)) {
+			d.track(chainValue{value: val, ref: templateRef(path, "")})
+		} else if len(val) >= minExactChainValueLength && isVolatileKey(key) {
+			d.track(chainValue{value: val, ref: templateRef(path, ""), exact: true})
+		}
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1e15 && isVolatileKey(key) {
+			if text := strconv.FormatFloat(val, 'f', -1, 64); len(text) >= minExactChainValueLength {
+				// the JSON numbers are float64, they are printed as integers by the sprig function
+				d.track(chainValue{value: text, ref: templateRef(path, "int64"), exact: true})
+			}
+		}
+	}
+}
+
+// track keeps a value, the later one takes precedence
+func (d *ChainDetector) track(item chainValue) {
+	if item.exact {
+		if existing, ok := d.values[item.value]; ok && existing.field != nil {
+			item.field = existing.field
+		} else {
+			quoted := regexp.QuoteMeta(item.value)
+			item.field = regexp.MustCompile(`(:\s*)(` + quoted + `|"` + quoted + `")(\s*[,}\]])`)
+		}
+	}
+	d.values[item.value] = item
+	d.sorted = nil
+}
+
+// ReplaceExact returns the template reference if the whole value is tracked
+func (d *ChainDetector) ReplaceExact(value string) (string, bool) {
+	if item, ok := d.values[value]; ok {
+		return item.ref, true
+	}
+	return value, false
+}
+
+// Replace replaces the tracked values inside of the text, the exact ones must match the whole text
+func (d *ChainDetector) Replace(text string) string {
+	if ref, ok := d.ReplaceExact(text); ok {
+		return ref
+	}
+	for _, item := range d.sortedValues() {
+		if !item.exact {
+			text = strings.ReplaceAll(text, item.value, item.ref)
+		}
+	}
+	return text
+}
+
+// ReplaceJSON replaces the tracked values inside of a JSON body,
+// the exact ones are replaced when they are the whole values of fields
+func (d *ChainDetector) ReplaceJSON(body string) string {
+	for _, item := range d.sortedValues() {
+		if !item.exact {
+			body = strings.ReplaceAll(body, item.value, item.ref)
+			continue
+		}
+
+		field := item.field
+		body = field.ReplaceAllStringFunc(body, func(match string) string {
+			groups := field.FindStringSubmatch(match)
+			ref := item.ref
+			if strings.HasPrefix(groups[2], `"`) {
+				ref = `"` + ref + `"`
+			}
+			return groups[1] + ref + groups[3]
+		})
+	}
+	return body
+}
+
+// TemplateURL replaces the tracked values of the path segments and query values. The template and params
+// come from NormalizePath, the params which are tracked values are replaced and removed from the result.
+func (d *ChainDetector) TemplateURL(u *url.URL, template string, params []PathParam) (api string, rest []PathParam) {
+	if template == "" {
+		template = u.EscapedPath()
+	}
+	for _, param := range params {
+		if ref, ok := d.ReplaceExact(param.Value); ok {
+			template = strings.Replace(template, "{"+param.Name+"}", ref, 1)
+		} else {
+			rest = append(rest, param)
+		}
+	}
+
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if value, err := url.PathUnescape(segment); err == nil {
+			if ref, ok := d.ReplaceExact(value); ok {
+				segments[i] = ref
+			}
+		}
+	}
+
+	api = RenderPathTemplate(strings.Join(segments, "/"), rest, "{{.param.%s}}")
+	if u.Host != "" {
+		api = fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, api)
+	}
+	if u.RawQuery != "" {
+		pairs := strings.Split(u.RawQuery, "&")
+		for i, pair := range pairs {
+			if key, value, ok := strings.Cut(pair, "="); ok {
+				if unescaped, err := url.QueryUnescape(value); err == nil {
+					if replaced := d.Replace(unescaped); replaced != unescaped {
+						pairs[i] = key + "=" + replaced
+					}
+				}
+			}
+		}
+		api += "?" + strings.Join(pairs, "&")
+	}
+	return
+}
+
+func (d *ChainDetector) sortedValues() (values []chainValue) {
+	if d.sorted != nil {
+		return d.sorted
+	}
+	values = make([]chainValue, 0, len(d.values))
+	for _, item := range d.values {
+		values = append(values, item)
+	}
+	// the longer values go first, then the shorter ones will not break them
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i].value) != len(values[j].value) {
+			return len(values[i].value) > len(values[j].value)
+		}
+		return values[i].value < values[j].value
+	})
+	d.sorted = values
+	return
+}
+
+// templateRef returns the api-testing template reference of a case output,
+// the index function is used if there is any key which is not an identifier
+func templateRef(path []interface{}, function string) (ref string) {
+	simple := true
+	for _, item := range path {
+		if key, ok := item.(string); !ok || !identifierPattern.MatchString(key) {
+			simple = false
+			break
+		}
+	}
+
+	if simple {
+		for _, item := range path {
+			ref += "." + item.(string)
+		}
+	} else {
+		args := []string{"index", "."}
+		for _, item := range path {
+			if key, ok := item.(string); ok {
+				args = append(args, strconv.Quote(key))
+			} else {
+				args = append(args, fmt.Sprint(item))
+			}
+		}
+		ref = strings.Join(args, " ")
+		if function != "" {
+			ref = "(" + ref + ")"
+		}
+	}
+
+	if function != "" {
+		ref = function + " " + ref
+	}
+	return "{{" + ref + "}}"
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/linuxsuren/api-testing/pkg/render"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleToken = "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxMjM0NTY3ODkwIn0"
+
+func TestChainDetector(t *testing.T) {
+	detector := pkg.NewChainDetector()
+	detector.Harvest("login", `{"data": {"token": "`+sampleToken+`", "expiresAt": "2026-10-18T08:00:00Z"}}`)
+	detector.Harvest("users-1", `{"items": [{"id": 1024, "uuid": "abc"}], "page": 1}`)
+	detector.Harvest("fake", "not json")
+
+	assert.Equal(t, "Bearer {{.login.data.token}}", detector.Replace("Bearer "+sampleToken))
+	assert.Equal(t, `{{index . "users-1" "items" 0 "uuid"}}`, detector.Replace("abc"))
+	assert.Equal(t, "xabc", detector.Replace("xabc"))
+	assert.Equal(t, "2026-10-18T08:00:00Z", detector.Replace("2026-10-18T08:00:00Z"))
+
+	ref, ok := detector.ReplaceExact("1024")
+	assert.True(t, ok)
+	assert.Equal(t, `{{int64 (index . "users-1" "items" 0 "id")}}`, ref)
+	_, ok = detector.ReplaceExact("1")
+	assert.False(t, ok)
+
+	assert.Equal(t, `{"userId": {{int64 (index . "users-1" "items" 0 "id")}}, "code": "{{index . "users-1" "items" 0 "uuid"}}", "count": 10245}`,
+		detector.ReplaceJSON(`{"userId": 1024, "code": "abc", "count": 10245}`))
+
+	u, _ := url.Parse("http://foo/users/1024/orders/7?token=" + sampleToken + "&page=1")
+	api, params := detector.TemplateURL(u, "/users/{userId}/orders/{orderId}",
+		[]pkg.PathParam{{Name: "userId", Value: "1024"}, {Name: "orderId", Value: "7"}})
+	assert.Equal(t, `http://foo/users/{{int64 (index . "users-1" "items" 0 "id")}}/orders/{{.param.orderId}}?token={{.login.data.token}}&page=1`, api)
+	assert.Equal(t, []pkg.PathParam{{Name: "orderId", Value: "7"}}, params)
+
+	u, _ = url.Parse("/users/1024")
+	api, params = detector.TemplateURL(u, "", nil)
+	assert.Equal(t, `/users/{{int64 (index . "users-1" "items" 0 "id")}}`, api)
+	assert.Empty(t, params)
+
+	// the later responses take precedence
+	detector.Harvest("user", `{"id": 1024}`)
+	assert.Equal(t, `{"userId": {{int64 .user.id}}}`, detector.ReplaceJSON(`{"userId": 1024}`))
+}
+
+func TestSampleExporterWithChain(t *testing.T) {
+	jsonHeader := http.Header{"Content-Type": []string{"application/json"}}
+	exporter := pkg.NewSampleExporter(false).WithChain(true)
+
+	request, _ := http.NewRequest(http.MethodPost, "http://foo/api/login", bytes.NewBufferString(`{"username": "rick"}`))
+	exporter.Add(&pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusOK, Header: jsonHeader,
+		Body: `{"data": {"token": "` + sampleToken + `"}}`,
+	}})
+
+	request, _ = http.NewRequest(http.MethodPost, "http://foo/api/users", bytes.NewBufferString(`{"name": "rick"}`))
+	request.Header.Set("Authorization", "Bearer "+sampleToken)
+	exporter.Add(&pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusCreated, Header: jsonHeader, Body: `{"id": 1024}`,
+	}})
+
+	request, _ = http.NewRequest(http.MethodPut, "http://foo/api/users/1024", bytes.NewBufferString(`{"id": 1024}`))
+	request.Header.Set("Authorization", "Bearer "+sampleToken)
+	request.Header.Set("X-Request-Id", "static")
+	exporter.Add(&pkg.RequestAndResponse{Request: request})
+
+	items := exporter.TestSuite.Items
+	assert.Len(t, items, 3)
+	assert.Equal(t, "Bearer {{.login.data.token}}", items[1].Request.Header["Authorization"])
+	assert.Equal(t, "http://foo/api/users/{{int64 .users.id}}", items[2].Request.API)
+	assert.Equal(t, `{"id": {{int64 .users.id}}}`, items[2].Request.Body)
+	assert.Equal(t, "static", items[2].Request.Header["X-Request-Id"])
+
+	// the references are rendered by api-testing with the outputs of the earlier cases
+	var login, users interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"data": {"token": "`+sampleToken+`"}}`), &login))
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": 1024}`), &users))
+	dataContext := map[string]interface{}{"login": login, "users": users}
+
+	result, err := render.Render("api", items[2].Request.API, dataContext)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://foo/api/users/1024", result)
+	result, err = render.Render("header", items[1].Request.Header["Authorization"], dataContext)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer "+sampleToken, result)
+}
+
+func TestSampleExporterChainSecretHeaders(t *testing.T) {
+	exporter := pkg.NewSampleExporter(false).WithChain(true)
+
+	request, _ := http.NewRequest(http.MethodPost, "http://foo/api/login", nil)
+	exporter.Add(&pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusOK, Header: http.Header{"Content-Type": []string{"application/json"}},
+		Body: `{"sessionId": "` + sampleToken + `"}`,
+	}})
+
+	request, _ = http.NewRequest(http.MethodGet, "http://foo/api/users", nil)
+	request.Header.Set("Cookie", "secret_token=SUPERSECRETVALUE; sid="+sampleToken)
+	request.Header.Set("Authorization", "Bearer SUPERSECRET2 "+sampleToken)
+	request.Header.Set("X-Session", "sid="+sampleToken)
+	exporter.Add(&pkg.RequestAndResponse{Request: request})
+
+	request, _ = http.NewRequest(http.MethodGet, "http://foo/api/orders", nil)
+	request.Header.Set("Authorization", "Bearer "+sampleToken)
+	exporter.Add(&pkg.RequestAndResponse{Request: request})
+
+	items := exporter.TestSuite.Items
+	assert.Len(t, items, 3)
+	// the secrets are never leaked when only a part of the value is tracked
+	assert.Equal(t, `{{env "COOKIE"}}`, items[1].Request.Header["Cookie"])
+	assert.Equal(t, `{{env "AUTHORIZATION"}}`, items[1].Request.Header["Authorization"])
+	assert.Equal(t, "sid={{.login.sessionId}}", items[1].Request.Header["X-Session"])
+	assert.Equal(t, "Bearer {{.login.sessionId}}", items[2].Request.Header["Authorization"])
+}
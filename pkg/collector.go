@@ -1,53 +1,278 @@
 package pkg
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Collects is a HTTP request collector
+// OverflowPolicy decides what to do with the new requests when the queue of the event handles is full
+type OverflowPolicy string
+
+const (
+	// OverflowDrop drops the request, it will be collected again if the same request comes later
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowBlock waits until the queue has room, it slows down the proxy
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowSpill writes the request into a temporary file, it is handled once the queue is empty
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+// DefaultQueueSize is the default size of the queue of the event handles
+const DefaultQueueSize = 1000
+
+// DefaultStopTimeout is the max time to deliver the pending requests when stopping the collector
+const DefaultStopTimeout = 10 * time.Second
+
+// OverflowPolicies are all the supported overflow policies
+var OverflowPolicies = []OverflowPolicy{OverflowDrop, OverflowBlock, OverflowSpill}
+
+// Collects is a HTTP request collector, it is safe for concurrent use.
+// The new requests are handled by the event handles in a goroutine through a bounded queue,
+// then the callers of Add are never blocked by the slow event handles unless the policy is OverflowBlock.
 type Collects struct {
 	once       sync.Once
-	signal     chan string
-	stopSignal chan struct{}
-	keys       map[string]*RequestAndResponse
-	requests   []*http.Request
+	lock       sync.RWMutex
+	queue      chan *RequestAndResponse
+	policy     OverflowPolicy
+	spill      *spillQueue
+	spillReady chan struct{}
+	stopSignal chan time.Time
+	done       chan struct{}
+	started    bool
+	pending    atomic.Int64
+	keys       map[string]bool
 	events     []EventHandle
+	rawEvents  []EventHandle
+	strategy   KeyStrategy
+	stats      collectsCounters
+}
+
+// CollectsStats are the counters of a collector
+type CollectsStats struct {
+	// Received is the number of all the added requests, including the duplicated ones
+	Received int64
+	// Unique is the number of the requests which have new keys
+	Unique int64
+	// Handled is the number of the requests which were handled by the event handles
+	Handled int64
+	// Dropped is the number of the requests which were dropped because the queue was full
+	Dropped int64
+	// Spilled is the number of the requests which were written into the spill file
+	Spilled int64
+	// Queued is the number of the requests which are waiting in the queue and the spill file
+	Queued int64
+}
+
+type collectsCounters struct {
+	received, unique, handled, dropped, spilled atomic.Int64
 }
 
 type SimpleResponse struct {
 	StatusCode int
+	Header     http.Header
 	Body       string
+	// StartedAt is the time when the request was received
+	StartedAt time.Time
+	// Duration is the time between receiving the request and the response
+	Duration time.Duration
+	// Truncated is true if the body is not complete, it is larger than the limit or interrupted
+	Truncated bool
+}
+
+// Events returns the events of the text/event-stream response
+func (r *SimpleResponse) Events() []ServerSentEvent {
+	if !IsEventStream(r.Header.Get("Content-Type")) {
+		return nil
+	}
+	return ParseServerSentEvents(r.Body)
 }
 
 type RequestAndResponse struct {
 	Request  *http.Request
 	Response *SimpleResponse
+	// Key is generated by the KeyStrategy when it is added into the collector
+	Key string
+
+	requestBody []byte
+	bodyRead    bool
+}
+
+// ReadRequestBody reads the request body once, then it could be read by all the event handles
+func (r *RequestAndResponse) ReadRequestBody() []byte {
+	if !r.bodyRead && r.Request != nil {
+		r.bodyRead = true
+		if r.Request.Body != nil {
+			r.requestBody, _ = io.ReadAll(r.Request.Body)
+			_ = r.Request.Body.Close()
+		}
+	}
+	if r.Request != nil && r.Request.Body != nil {
+		r.Request.Body = io.NopCloser(bytes.NewReader(r.requestBody))
+	}
+	return r.requestBody
+}
+
+// Clone returns a copy which has its own request, the request body is read before copying.
+// The copy could be handled in another goroutine, the response is shared because it is never changed.
+func (r *RequestAndResponse) Clone() *RequestAndResponse {
+	body := r.ReadRequestBody()
+	clone := &RequestAndResponse{Response: r.Response, Key: r.Key, requestBody: body, bodyRead: true}
+	if r.Request != nil {
+		clone.Request = r.Request.Clone(r.Request.Context())
+		if r.Request.Body != nil {
+			clone.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	}
+	return clone
 }
 
 // NewCollects creates an instance of Collector
 func NewCollects() *Collects {
 	return &Collects{
 		once:       sync.Once{},
-		signal:     make(chan string, 5),
-		stopSignal: make(chan struct{}, 1),
-		keys:       make(map[string]*RequestAndResponse),
+		queue:      make(chan *RequestAndResponse, DefaultQueueSize),
+		policy:     OverflowDrop,
+		spillReady: make(chan struct{}, 1),
+		stopSignal: make(chan time.Time, 1),
+		done:       make(chan struct{}),
+		keys:       make(map[string]bool),
+		strategy:   &URLKeyStrategy{},
 	}
 }
 
-// Add adds a HTTP request
+// SetKeyStrategy sets the strategy to deduplicate the requests
+func (c *Collects) SetKeyStrategy(strategy KeyStrategy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.strategy = strategy
+}
+
+// SetQueueSize sets the size of the queue of the event handles, it should be called before adding any event handle
+func (c *Collects) SetQueueSize(size int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	c.queue = make(chan *RequestAndResponse, size)
+}
+
+// SetOverflowPolicy sets the policy when the queue of the event handles is full
+func (c *Collects) SetOverflowPolicy(policy OverflowPolicy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.policy = policy
+}
+
+// Add adds a HTTP request, the raw event handles are called in the goroutine of the caller
 func (c *Collects) Add(req *http.Request, resp *SimpleResponse) {
-	key := fmt.Sprintf("%s-%s", req.Method, req.URL.String())
-	if _, ok := c.keys[key]; !ok {
-		c.keys[key] = &RequestAndResponse{
-			Request:  req,
-			Response: resp,
+	reqAndResp := &RequestAndResponse{
+		Request:  req,
+		Response: resp,
+	}
+	c.stats.received.Add(1)
+
+	c.lock.RLock()
+	strategy, rawEvents, hasEvents := c.strategy, c.rawEvents, len(c.events) > 0
+	queue, policy := c.queue, c.policy
+	c.lock.RUnlock()
+
+	key := strategy.Key(reqAndResp)
+	reqAndResp.Key = key
+	for _, e := range rawEvents {
+		e(reqAndResp)
+	}
+
+	c.lock.Lock()
+	if c.keys[key] {
+		c.lock.Unlock()
+		return
+	}
+	c.keys[key] = true
+	c.lock.Unlock()
+	c.stats.unique.Add(1)
+
+	if hasEvents {
+		c.enqueue(reqAndResp, queue, policy)
+	}
+}
+
+func (c *Collects) enqueue(reqAndResp *RequestAndResponse, queue chan *RequestAndResponse, policy OverflowPolicy) {
+	c.pending.Add(1)
+	select {
+	case queue <- reqAndResp:
+		return
+	default:
+	}
+
+	switch policy {
+	case OverflowBlock:
+		select {
+		case queue <- reqAndResp:
+			return
+		case <-c.done:
+		}
+	case OverflowSpill:
+		err := c.spillRequest(reqAndResp)
+		if err == nil {
+			return
+		}
+		log.Println("failed to spill the request", err)
+	}
+
+	// forget the key, then the same request could be collected again
+	c.lock.Lock()
+	delete(c.keys, reqAndResp.Key)
+	c.lock.Unlock()
+	c.stats.unique.Add(-1)
+	c.stats.dropped.Add(1)
+	c.pending.Add(-1)
+}
+
+func (c *Collects) spillRequest(reqAndResp *RequestAndResponse) (err error) {
+	c.lock.Lock()
+	if c.spill == nil {
+		c.spill, err = newSpillQueue()
+	}
+	spill := c.spill
+	c.lock.Unlock()
+
+	if err == nil {
+		if err = spill.Push(reqAndResp); err == nil {
+			c.stats.spilled.Add(1)
+			select {
+			case c.spillReady <- struct{}{}:
+			default:
+			}
 		}
-		c.requests = append(c.requests, req)
-		c.signal <- key
 	}
+	return
+}
+
+// Stats returns the counters of the collector
+func (c *Collects) Stats() (stats CollectsStats) {
+	stats = CollectsStats{
+		Received: c.stats.received.Load(),
+		Unique:   c.stats.unique.Load(),
+		Handled:  c.stats.handled.Load(),
+		Dropped:  c.stats.dropped.Load(),
+		Spilled:  c.stats.spilled.Load(),
+	}
+	c.lock.RLock()
+	stats.Queued = int64(len(c.queue))
+	if c.spill != nil {
+		stats.Queued += int64(c.spill.Len())
+	}
+	c.lock.RUnlock()
+	return
 }
 
 // EventHandle is the collect event handle
@@ -55,32 +280,188 @@ type EventHandle func(r *RequestAndResponse)
 
 // AddEvent adds new event handle
 func (c *Collects) AddEvent(e EventHandle) {
+	c.lock.Lock()
 	c.events = append(c.events, e)
+	c.lock.Unlock()
 	c.handleEvents()
 }
 
-// Stop stops the collector
+// AddRawEvent adds new event handle which receives all the requests, including the duplicated ones.
+// It is called in the goroutine of Add.
+func (c *Collects) AddRawEvent(e EventHandle) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.rawEvents = append(c.rawEvents, e)
+}
+
+// Stop stops the collector after delivering the pending requests, it waits DefaultStopTimeout at most
 func (c *Collects) Stop() {
-	c.stopSignal <- struct{}{}
+	if err := c.StopWithTimeout(DefaultStopTimeout); err != nil {
+		log.Println(err)
+	}
+}
+
+// StopWithTimeout stops the collector after delivering the pending requests to the event handles,
+// the rest of them are dropped once the timeout is reached. The spill file is removed.
+func (c *Collects) StopWithTimeout(timeout time.Duration) (err error) {
+	c.lock.RLock()
+	started := c.started
+	c.lock.RUnlock()
+	if !started {
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c.stopSignal <- time.Now().Add(timeout):
+	case <-c.done:
+		return
+	}
+	select {
+	case <-c.done:
+	case <-timer.C:
+	}
+	if pending := c.pending.Load(); pending > 0 {
+		err = fmt.Errorf("the collector is stopped with %d pending requests", pending)
+	}
+	return
+}
+
+// Wait waits until all the pending requests are delivered to the event handles
+func (c *Collects) Wait(ctx context.Context) (err error) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for c.pending.Load() > 0 && err == nil {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-c.done:
+			err = errors.New("the collector is stopped")
+		case <-ticker.C:
+		}
+	}
+	return
 }
 
 func (c *Collects) handleEvents() {
 	log.Println("handle events")
 	c.once.Do(func() {
+		c.lock.Lock()
+		queue := c.queue
+		c.started = true
+		c.lock.Unlock()
+
 		go func() {
 			log.Println("start handle events")
+			defer close(c.done)
+			defer c.closeSpill()
 			for {
+				// the stop signal goes first, then the queued requests are handled until the deadline only
 				select {
-				case key := <-c.signal:
-					log.Println("receive signal", key)
-					for _, e := range c.events {
-						e(c.keys[key])
-					}
-				case <-c.stopSignal:
+				case deadline := <-c.stopSignal:
 					log.Println("stop")
+					c.drain(queue, deadline)
+					return
+				default:
+				}
+
+				select {
+				case reqAndResp := <-queue:
+					log.Println("receive signal", reqAndResp.Key)
+					c.handle(reqAndResp)
+				case <-c.spillReady:
+					c.handleSpilled(queue)
+				case deadline := <-c.stopSignal:
+					log.Println("stop")
+					c.drain(queue, deadline)
 					return
 				}
 			}
 		}()
 	})
 }
+
+func (c *Collects) handle(reqAndResp *RequestAndResponse) {
+	c.lock.RLock()
+	events := c.events
+	c.lock.RUnlock()
+	for _, e := range events {
+		e(reqAndResp)
+	}
+	c.stats.handled.Add(1)
+	c.pending.Add(-1)
+}
+
+// drain handles the queued and spilled requests until the deadline
+func (c *Collects) drain(queue chan *RequestAndResponse, deadline time.Time) {
+	for time.Now().Before(deadline) {
+		select {
+		case reqAndResp := <-queue:
+			c.handle(reqAndResp)
+			continue
+		default:
+		}
+
+		if reqAndResp := c.popSpilled(); reqAndResp != nil {
+			c.handle(reqAndResp)
+			continue
+		}
+		return
+	}
+
+	if dropped := c.pending.Load(); dropped > 0 {
+		log.Println("dropped", dropped, "pending requests after the timeout")
+		c.stats.dropped.Add(dropped)
+	}
+}
+
+// handleSpilled handles the spilled requests once the queue is empty
+func (c *Collects) handleSpilled(queue chan *RequestAndResponse) {
+	for len(queue) == 0 {
+		reqAndResp := c.popSpilled()
+		if reqAndResp == nil {
+			return
+		}
+		c.handle(reqAndResp)
+	}
+	// come back after the queue is empty
+	select {
+	case c.spillReady <- struct{}{}:
+	default:
+	}
+}
+
+// popSpilled returns the first spilled request, the unreadable ones are skipped
+func (c *Collects) popSpilled() *RequestAndResponse {
+	c.lock.RLock()
+	spill, strategy := c.spill, c.strategy
+	c.lock.RUnlock()
+	if spill == nil {
+		return nil
+	}
+
+	for {
+		reqAndResp, dropped, err := spill.Pop()
+		if err == nil {
+			if reqAndResp != nil {
+				reqAndResp.Key = strategy.Key(reqAndResp)
+			}
+			return reqAndResp
+		}
+		log.Println("failed to read the spilled requests", err)
+		c.stats.dropped.Add(int64(dropped))
+		c.pending.Add(-int64(dropped))
+	}
+}
+
+func (c *Collects) closeSpill() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.spill != nil {
+		if err := c.spill.Close(); err != nil {
+			log.Println("failed to remove the spill file", err)
+		}
+		c.spill = nil
+	}
+}
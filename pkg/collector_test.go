@@ -1,8 +1,13 @@
 package pkg_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/linuxsuren/atest-ext-collector/pkg"
 	"github.com/stretchr/testify/assert"
@@ -32,3 +37,208 @@ func TestCollector(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectorConcurrency(t *testing.T) {
+	collects := pkg.NewCollects()
+	var lock sync.Mutex
+	received := map[string]int{}
+	done := make(chan struct{})
+	collects.AddEvent(func(r *pkg.RequestAndResponse) {
+		lock.Lock()
+		defer lock.Unlock()
+		if received[r.Key]++; len(received) == 10 {
+			close(done)
+		}
+	})
+	defer collects.Stop()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://foo.com/%d", i%10), nil)
+			collects.Add(request, nil)
+		}(i)
+	}
+	wg.Wait()
+	<-done
+
+	for key, count := range received {
+		assert.Equal(t, 1, count, key)
+	}
+	stats := collects.Stats()
+	assert.Equal(t, int64(100), stats.Received)
+	assert.Equal(t, int64(10), stats.Unique)
+}
+
+func TestCollectorOverflow(t *testing.T) {
+	newCollects := func(policy pkg.OverflowPolicy) (collects *pkg.Collects, received chan string, release chan struct{}) {
+		collects = pkg.NewCollects()
+		collects.SetQueueSize(1)
+		collects.SetOverflowPolicy(policy)
+		received = make(chan string, 10)
+		release = make(chan struct{})
+		collects.AddEvent(func(r *pkg.RequestAndResponse) {
+			<-release
+			received <- r.Request.URL.Path
+		})
+		return
+	}
+	add := func(collects *pkg.Collects, path string) {
+		request, _ := http.NewRequest(http.MethodGet, "http://foo.com"+path, nil)
+		collects.Add(request, &pkg.SimpleResponse{StatusCode: http.StatusOK})
+	}
+	waitQueued := func(collects *pkg.Collects, queued int64) {
+		assert.Eventually(t, func() bool {
+			return collects.Stats().Queued == queued
+		}, time.Second, time.Millisecond)
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		collects, received, release := newCollects(pkg.OverflowDrop)
+		defer collects.Stop()
+
+		add(collects, "/1") // being handled
+		waitQueued(collects, 0)
+		add(collects, "/2") // queued
+		add(collects, "/3") // dropped
+		stats := collects.Stats()
+		assert.Equal(t, int64(1), stats.Dropped)
+		assert.Equal(t, int64(2), stats.Unique)
+
+		close(release)
+		assert.Equal(t, "/1", <-received)
+		assert.Equal(t, "/2", <-received)
+
+		// the dropped request could be collected again
+		add(collects, "/3")
+		assert.Equal(t, "/3", <-received)
+	})
+
+	t.Run("spill", func(t *testing.T) {
+		collects, received, release := newCollects(pkg.OverflowSpill)
+		defer collects.Stop()
+
+		add(collects, "/1")
+		waitQueued(collects, 0)
+		add(collects, "/2")
+		add(collects, "/3")
+		add(collects, "/4")
+		stats := collects.Stats()
+		assert.Equal(t, int64(2), stats.Spilled)
+		assert.Equal(t, int64(3), stats.Queued)
+
+		close(release)
+		for _, path := range []string{"/1", "/2", "/3", "/4"} {
+			assert.Equal(t, path, <-received)
+		}
+		assert.Eventually(t, func() bool {
+			return collects.Stats().Handled == 4
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("spill binary", func(t *testing.T) {
+		collects := pkg.NewCollects()
+		collects.SetQueueSize(1)
+		collects.SetOverflowPolicy(pkg.OverflowSpill)
+		defer collects.Stop()
+		bodies := make(chan []byte, 10)
+		release := make(chan struct{})
+		collects.AddEvent(func(r *pkg.RequestAndResponse) {
+			<-release
+			bodies <- r.ReadRequestBody()
+		})
+
+		for i := 1; i <= 3; i++ {
+			request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("http://foo.com/%d", i), bytes.NewBuffer(binaryBody))
+			collects.Add(request, &pkg.SimpleResponse{StatusCode: http.StatusOK})
+			waitQueued(collects, int64(i-1))
+		}
+		assert.Equal(t, int64(1), collects.Stats().Spilled)
+
+		close(release)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, binaryBody, <-bodies)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		collects, received, release := newCollects(pkg.OverflowBlock)
+		defer collects.Stop()
+
+		add(collects, "/1")
+		waitQueued(collects, 0)
+		add(collects, "/2")
+		added := make(chan struct{})
+		go func() {
+			add(collects, "/3")
+			close(added)
+		}()
+		select {
+		case <-added:
+			t.Fatal("the request should be blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		<-added
+		for _, path := range []string{"/1", "/2", "/3"} {
+			assert.Equal(t, path, <-received)
+		}
+		assert.Zero(t, collects.Stats().Dropped)
+	})
+}
+
+func TestCollectorStop(t *testing.T) {
+	newCollects := func(delay time.Duration) (collects *pkg.Collects, received chan string) {
+		collects = pkg.NewCollects()
+		received = make(chan string, 10)
+		collects.AddEvent(func(r *pkg.RequestAndResponse) {
+			time.Sleep(delay)
+			received <- r.Request.URL.Path
+		})
+		for i := 0; i < 5; i++ {
+			request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://foo.com/%d", i), nil)
+			collects.Add(request, nil)
+		}
+		return
+	}
+
+	t.Run("wait", func(t *testing.T) {
+		collects, received := newCollects(time.Millisecond)
+		defer collects.Stop()
+		assert.NoError(t, collects.Wait(context.Background()))
+		assert.Len(t, received, 5)
+		assert.Equal(t, int64(5), collects.Stats().Handled)
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		collects, received := newCollects(10 * time.Millisecond)
+		assert.NoError(t, collects.StopWithTimeout(time.Second))
+		assert.Len(t, received, 5)
+		// stop twice
+		assert.NoError(t, collects.StopWithTimeout(time.Second))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, collects.Wait(ctx))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		collects, _ := newCollects(50 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Error(t, collects.Wait(ctx))
+
+		assert.Error(t, collects.StopWithTimeout(20*time.Millisecond))
+		assert.Eventually(t, func() bool {
+			return collects.Stats().Dropped > 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("not started", func(t *testing.T) {
+		collects := pkg.NewCollects()
+		assert.NoError(t, collects.StopWithTimeout(time.Second))
+	})
+}
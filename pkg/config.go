@@ -0,0 +1,56 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CollectorConfig is the config file of the collector
+type CollectorConfig struct {
+	ContentType *ContentTypePolicy `yaml:"contentType"`
+	Header      *HeaderPolicy      `yaml:"header"`
+}
+
+// ParseCollectorConfig reads the collector config from a file
+func ParseCollectorConfig(file string) (config *CollectorConfig, err error) {
+	var data []byte
+	if data, err = os.ReadFile(file); err == nil {
+		config, err = ParseCollectorConfigFromBuffer(data)
+	}
+	return
+}
+
+// ParseCollectorConfigFromBuffer parses the collector config
+func ParseCollectorConfigFromBuffer(data []byte) (config *CollectorConfig, err error) {
+	config = &CollectorConfig{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return
+	}
+
+	if config.ContentType != nil {
+		if err = config.ContentType.Validate(); err != nil {
+			return
+		}
+	}
+	if config.Header != nil {
+		err = config.Header.Validate()
+	}
+	return
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCollectorConfig(t *testing.T) {
+	config, err := pkg.ParseCollectorConfig("testdata/collector_config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, &pkg.ContentTypePolicy{
+		Allow:  []string{"application/json", "application/x-www-form-urlencoded", "text/*"},
+		Deny:   []string{"text/html"},
+		Binary: pkg.BinaryModeBase64,
+	}, config.ContentType)
+	assert.Equal(t, &pkg.HeaderPolicy{
+		Drop: []string{"User-Agent"},
+		Mask: []string{"X-Tenant-Secret"},
+		Mode: pkg.SecretModeMask,
+	}, config.Header)
+
+	_, err = pkg.ParseCollectorConfig("testdata/fake.yaml")
+	assert.Error(t, err)
+
+	_, err = pkg.ParseCollectorConfigFromBuffer([]byte("contentType:\n  binary: fake"))
+	assert.Error(t, err)
+
+	_, err = pkg.ParseCollectorConfigFromBuffer([]byte("header:\n  mode: fake"))
+	assert.Error(t, err)
+
+	_, err = pkg.ParseCollectorConfigFromBuffer([]byte("fake"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// BodyKind is the kind of a HTTP body
+type BodyKind string
+
+const (
+	BodyKindJSON   BodyKind = "json"
+	BodyKindForm   BodyKind = "form"
+	BodyKindXML    BodyKind = "xml"
+	BodyKindText   BodyKind = "text"
+	BodyKindBinary BodyKind = "binary"
+)
+
+// GetBodyKind returns the body kind of the content type
+func GetBodyKind(contentType string) BodyKind {
+	mediaType := getMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return BodyKindJSON
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data":
+		return BodyKindForm
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return BodyKindXML
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/javascript" ||
+		mediaType == "application/x-ndjson" || mediaType == "application/graphql":
+		return BodyKindText
+	}
+	return BodyKindBinary
+}
+
+// BinaryMode is the way to keep the binary bodies
+type BinaryMode string
+
+const (
+	// BinaryModeSummary keeps the size and checksum of the binary body only
+	BinaryModeSummary BinaryMode = "summary"
+	// BinaryModeBase64 keeps the base64 encoded binary body
+	BinaryModeBase64 BinaryMode = "base64"
+)
+
+// DefaultContentTypes are the content types captured by default
+var DefaultContentTypes = []string{"application/json", "application/*+json", "text/event-stream"}
+
+// ContentTypePolicy decides which responses will be captured by the content type
+type ContentTypePolicy struct {
+	// Allow are the media type patterns to capture, for example: application/json, text/*
+	Allow []string `yaml:"allow"`
+	// Deny are the media type patterns to ignore, it takes precedence over Allow
+	Deny   []string   `yaml:"deny"`
+	Binary BinaryMode `yaml:"binary"`
+}
+
+// NewContentTypePolicy creates the default content type policy
+func NewContentTypePolicy() *ContentTypePolicy {
+	return &ContentTypePolicy{
+		Allow:  append([]string{}, DefaultContentTypes...),
+		Binary: BinaryModeSummary,
+	}
+}
+
+// Validate checks the patterns and the binary mode
+func (p *ContentTypePolicy) Validate() (err error) {
+	for _, patterns := range [][]string{p.Allow, p.Deny} {
+		for _, pattern := range patterns {
+			if _, err = path.Match(pattern, ""); err != nil {
+				err = fmt.Errorf("invalid content type pattern %q: %v", pattern, err)
+				return
+			}
+		}
+	}
+
+	switch p.Binary {
+	case "", BinaryModeSummary, BinaryModeBase64:
+	default:
+		err = fmt.Errorf("not support binary mode: %q", p.Binary)
+	}
+	return
+}
+
+// Match returns true if the content type should be captured
+func (p *ContentTypePolicy) Match(contentType string) bool {
+	mediaType := getMediaType(contentType)
+	if mediaType == "" {
+		return false
+	}
+	return !matchMediaType(p.Deny, mediaType) && matchMediaType(p.Allow, mediaType)
+}
+
+func matchMediaType(patterns []string, mediaType string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func getMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// ParseForm parses the urlencoded or multipart form body into fields,
+// the uploaded files are ignored
+func ParseForm(contentType string, body []byte) (fields map[string]string, err error) {
+	fields, _, err = ParseFormFiles(contentType, body)
+	return
+}
+
+// ParseFormFiles parses the form body like ParseForm, it returns the field names of the uploaded files as well
+func ParseFormFiles(contentType string, body []byte) (fields map[string]string, files []string, err error) {
+	var values url.Values
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" {
+		values, files, err = parseMultipartForm(params["boundary"], body)
+	} else {
+		values, err = url.ParseQuery(string(body))
+	}
+
+	if err == nil {
+		fields = make(map[string]string, len(values))
+		for key := range values {
+			fields[key] = values.Get(key)
+		}
+	}
+	return
+}
+
+func parseMultipartForm(boundary string, body []byte) (values url.Values, files []string, err error) {
+	values = url.Values{}
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		var part *multipart.Part
+		if part, err = reader.NextPart(); err == io.EOF {
+			err = nil
+			return
+		} else if err != nil {
+			return
+		}
+
+		if part.FileName() == "" {
+			var data []byte
+			if data, err = io.ReadAll(part); err != nil {
+				return
+			}
+			values.Add(part.FormName(), string(data))
+		} else {
+			files = append(files, part.FormName())
+		}
+		_ = part.Close()
+	}
+}
+
+// EncodeBinaryBody returns the text of a binary body for api-testing.
+// The summary mode renders to an empty body, the base64 mode renders to the original one.
+func EncodeBinaryBody(body []byte, mode BinaryMode) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if mode == BinaryModeBase64 {
+		return fmt.Sprintf(`{{b64dec "%s"}}`, base64.StdEncoding.EncodeToString(body))
+	}
+	return "{{/* " + SummarizeBinaryBody(body) + " */}}"
+}
+
+// SummarizeBinaryBody returns the size and checksum of a binary body
+func SummarizeBinaryBody(body []byte) string {
+	return fmt.Sprintf("binary body: %d bytes, sha256: %x", len(body), sha256.Sum256(body))
+}
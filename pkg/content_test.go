@@ -0,0 +1,91 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBodyKind(t *testing.T) {
+	tests := map[string]pkg.BodyKind{
+		"application/json; charset=utf-8":   pkg.BodyKindJSON,
+		"application/problem+json":          pkg.BodyKindJSON,
+		"application/x-www-form-urlencoded": pkg.BodyKindForm,
+		"multipart/form-data; boundary=abc": pkg.BodyKindForm,
+		"text/xml":                          pkg.BodyKindXML,
+		"application/soap+xml":              pkg.BodyKindXML,
+		"text/plain":                        pkg.BodyKindText,
+		"application/x-protobuf":            pkg.BodyKindBinary,
+		"application/octet-stream":          pkg.BodyKindBinary,
+	}
+	for contentType, kind := range tests {
+		assert.Equal(t, kind, pkg.GetBodyKind(contentType), contentType)
+	}
+}
+
+func TestContentTypePolicy(t *testing.T) {
+	policy := pkg.NewContentTypePolicy()
+	assert.NoError(t, policy.Validate())
+	assert.True(t, policy.Match("application/json; charset=utf-8"))
+	assert.True(t, policy.Match("application/problem+json"))
+	assert.False(t, policy.Match("text/plain"))
+	assert.False(t, policy.Match(""))
+
+	policy = &pkg.ContentTypePolicy{
+		Allow: []string{"text/*", "application/xml"},
+		Deny:  []string{"text/html"},
+	}
+	assert.True(t, policy.Match("text/plain"))
+	assert.True(t, policy.Match("Application/XML"))
+	assert.False(t, policy.Match("text/html; charset=utf-8"))
+
+	assert.Error(t, (&pkg.ContentTypePolicy{Allow: []string{"["}}).Validate())
+	assert.Error(t, (&pkg.ContentTypePolicy{Binary: "fake"}).Validate())
+}
+
+func TestParseForm(t *testing.T) {
+	fields, err := pkg.ParseForm("application/x-www-form-urlencoded", []byte("name=rick&age=18"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "rick", "age": "18"}, fields)
+
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+	assert.NoError(t, writer.WriteField("name", "rick"))
+	file, err := writer.CreateFormFile("file", "a.txt")
+	assert.NoError(t, err)
+	_, _ = file.Write([]byte("content"))
+	assert.NoError(t, writer.Close())
+
+	fields, err = pkg.ParseForm(writer.FormDataContentType(), buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "rick"}, fields)
+
+	_, err = pkg.ParseForm("application/x-www-form-urlencoded", []byte("%zz"))
+	assert.Error(t, err)
+}
+
+func TestEncodeBinaryBody(t *testing.T) {
+	assert.Empty(t, pkg.EncodeBinaryBody(nil, pkg.BinaryModeBase64))
+	assert.Equal(t, `{{b64dec "aGVsbG8="}}`, pkg.EncodeBinaryBody([]byte("hello"), pkg.BinaryModeBase64))
+	assert.Equal(t, "{{/* binary body: 5 bytes, sha256: 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824 */}}",
+		pkg.EncodeBinaryBody([]byte("hello"), pkg.BinaryModeSummary))
+}
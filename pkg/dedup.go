@@ -0,0 +1,165 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// KeyStrategy generates the key to deduplicate the collected requests
+type KeyStrategy interface {
+	Key(reqAndResp *RequestAndResponse) string
+}
+
+// URLKeyStrategy deduplicates the requests by the method and the full URL
+type URLKeyStrategy struct{}
+
+// Key implements the KeyStrategy
+func (s *URLKeyStrategy) Key(reqAndResp *RequestAndResponse) string {
+	req := reqAndResp.Request
+	return fmt.Sprintf("%s-%s", req.Method, req.URL.String())
+}
+
+// NormalizedKeyStrategy deduplicates the requests by the method and the normalized URL,
+// the path parameters are detected and the order of query parameters is ignored
+type NormalizedKeyStrategy struct {
+	// BodyFingerprint takes the request body into account
+	BodyFingerprint bool
+}
+
+// Key implements the KeyStrategy
+func (s *NormalizedKeyStrategy) Key(reqAndResp *RequestAndResponse) string {
+	req := reqAndResp.Request
+	template, _ := NormalizePath(req.URL.Path)
+
+	key := fmt.Sprintf("%s-%s://%s%s", req.Method, req.URL.Scheme, req.URL.Host, template)
+	if query := req.URL.Query(); len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	if s.BodyFingerprint {
+		if body := reqAndResp.ReadRequestBody(); len(body) > 0 {
+			key += "#" + BodyFingerprint(body)
+		}
+	}
+	return key
+}
+
+// PathParam is a parameter which is detected from the URL path
+type PathParam struct {
+	Name  string
+	Value string
+}
+
+var (
+	numericSegment = regexp.MustCompile(`^\d+$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hashSegment    = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+)
+
+// IsPathParam returns true if the path segment looks like an identifier,
+// they are numbers, UUIDs and hashes
+func IsPathParam(segment string) bool {
+	return numericSegment.MatchString(segment) || uuidSegment.MatchString(segment) || hashSegment.MatchString(segment)
+}
+
+// NormalizePath replaces the path parameters with placeholders, for example:
+// /users/1/orders/2 becomes /users/{userId}/orders/{orderId}
+func NormalizePath(path string) (template string, params []PathParam) {
+	original := strings.Split(path, "/")
+	segments := make([]string, len(original))
+	copy(segments, original)
+
+	names := map[string]int{}
+	for i, segment := range original {
+		if !IsPathParam(segment) {
+			continue
+		}
+
+		name := "id"
+		if i > 0 && original[i-1] != "" && !IsPathParam(original[i-1]) {
+			name = toParamName(original[i-1])
+		}
+		if names[name]++; names[name] > 1 {
+			name = fmt.Sprintf("%s%d", name, names[name])
+		}
+
+		params = append(params, PathParam{Name: name, Value: segment})
+		segments[i] = "{" + name + "}"
+	}
+	template = strings.Join(segments, "/")
+	return
+}
+
+// RenderPathTemplate replaces the placeholders of a normalized path with the given format,
+// for example, the format of api-testing parameters is {{.param.%s}}
+func RenderPathTemplate(template string, params []PathParam, format string) string {
+	for _, param := range params {
+		template = strings.Replace(template, "{"+param.Name+"}", fmt.Sprintf(format, param.Name), 1)
+	}
+	return template
+}
+
+func toParamName(segment string) string {
+	words := strings.FieldsFunc(segment, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "id"
+	}
+
+	name := strings.ToLower(words[0])
+	for _, word := range words[1:] {
+		name += strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+	if strings.HasSuffix(name, "ies") {
+		name = strings.TrimSuffix(name, "ies") + "y"
+	} else if strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") {
+		name = strings.TrimSuffix(name, "s")
+	}
+	return name + "Id"
+}
+
+// BodyFingerprint returns a short checksum of the body, the key order of JSON objects is ignored
+func BodyFingerprint(body []byte) string {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err == nil {
+		// encoding/json sorts the keys of maps
+		if normalized, err := json.Marshal(data); err == nil {
+			body = normalized
+		}
+	}
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// templateURL returns the URL whose path parameters are replaced with the api-testing parameters
+func templateURL(u *url.URL, template string, params []PathParam) string {
+	result := RenderPathTemplate(template, params, "{{.param.%s}}")
+	if u.Host != "" {
+		result = fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, result)
+	}
+	if u.RawQuery != "" {
+		result += "?" + u.RawQuery
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		template string
+		params   []pkg.PathParam
+	}{{
+		path:     "/api/v1/users",
+		template: "/api/v1/users",
+	}, {
+		path:     "/users/1/orders/2",
+		template: "/users/{userId}/orders/{orderId}",
+		params:   []pkg.PathParam{{Name: "userId", Value: "1"}, {Name: "orderId", Value: "2"}},
+	}, {
+		path:     "/categories/7d9f4c1e-3b2a-4c5d-8e6f-0a1b2c3d4e5f",
+		template: "/categories/{categoryId}",
+		params:   []pkg.PathParam{{Name: "categoryId", Value: "7d9f4c1e-3b2a-4c5d-8e6f-0a1b2c3d4e5f"}},
+	}, {
+		path:     "/commits/5f1d3c2b9a8e7d6c5b4a/files/1/2",
+		template: "/commits/{commitId}/files/{fileId}/{id}",
+		params: []pkg.PathParam{{Name: "commitId", Value: "5f1d3c2b9a8e7d6c5b4a"},
+			{Name: "fileId", Value: "1"}, {Name: "id", Value: "2"}},
+	}, {
+		path:     "/1/user-groups/2/user-groups/3",
+		template: "/{id}/user-groups/{userGroupId}/user-groups/{userGroupId2}",
+		params: []pkg.PathParam{{Name: "id", Value: "1"}, {Name: "userGroupId", Value: "2"},
+			{Name: "userGroupId2", Value: "3"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			template, params := pkg.NormalizePath(tt.path)
+			assert.Equal(t, tt.template, template)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+
+	assert.Equal(t, "/users/:userId", pkg.RenderPathTemplate("/users/{userId}",
+		[]pkg.PathParam{{Name: "userId"}}, ":%s"))
+	assert.False(t, pkg.IsPathParam("v1"))
+	assert.False(t, pkg.IsPathParam("abcdef"))
+}
+
+func TestKeyStrategy(t *testing.T) {
+	newReqAndResp := func(method, api, body string) *pkg.RequestAndResponse {
+		request, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+		return &pkg.RequestAndResponse{Request: request}
+	}
+
+	strategy := &pkg.URLKeyStrategy{}
+	assert.Equal(t, "GET-http://foo/users/1", strategy.Key(newReqAndResp(http.MethodGet, "http://foo/users/1", "")))
+
+	normalized := &pkg.NormalizedKeyStrategy{}
+	assert.Equal(t, normalized.Key(newReqAndResp(http.MethodGet, "http://foo/users/1?b=2&a=1", "")),
+		normalized.Key(newReqAndResp(http.MethodGet, "http://foo/users/2?a=1&b=2", "")))
+	assert.NotEqual(t, normalized.Key(newReqAndResp(http.MethodGet, "http://foo/users/1", "")),
+		normalized.Key(newReqAndResp(http.MethodDelete, "http://foo/users/1", "")))
+	assert.Equal(t, normalized.Key(newReqAndResp(http.MethodPost, "http://foo/users", `{"name":"a"}`)),
+		normalized.Key(newReqAndResp(http.MethodPost, "http://foo/users", `{"name":"b"}`)))
+
+	fingerprint := &pkg.NormalizedKeyStrategy{BodyFingerprint: true}
+	assert.NotEqual(t, fingerprint.Key(newReqAndResp(http.MethodPost, "http://foo/users", `{"name":"a"}`)),
+		fingerprint.Key(newReqAndResp(http.MethodPost, "http://foo/users", `{"name":"b"}`)))
+	assert.Equal(t, fingerprint.Key(newReqAndResp(http.MethodPost, "http://foo/users", `{"a":1,"b":2}`)),
+		fingerprint.Key(newReqAndResp(http.MethodPost, "http://foo/users", `{"b":2, "a":1}`)))
+
+	reqAndResp := newReqAndResp(http.MethodPost, "http://foo/users", "hello")
+	fingerprint.Key(reqAndResp)
+	assert.Equal(t, "hello", string(reqAndResp.ReadRequestBody()))
+}
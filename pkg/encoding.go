@@ -0,0 +1,106 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ParseContentEncoding returns the codings of a Content-Encoding header in the order they were applied,
+// the identity coding is ignored and x-gzip is taken as gzip
+func ParseContentEncoding(contentEncoding string) (codings []string) {
+	for _, coding := range strings.Split(contentEncoding, ",") {
+		switch coding = strings.ToLower(strings.TrimSpace(coding)); coding {
+		case "", "identity":
+		case "x-gzip":
+			codings = append(codings, "gzip")
+		default:
+			codings = append(codings, coding)
+		}
+	}
+	return
+}
+
+// DecodeBody decodes a body by its Content-Encoding header, the codings are removed in the reverse order.
+// The decoded data is returned together with the error, then the truncated bodies are decoded as much as possible.
+func DecodeBody(data []byte, contentEncoding string) (decoded []byte, err error) {
+	decoded = data
+	codings := ParseContentEncoding(contentEncoding)
+	for i := len(codings) - 1; i >= 0 && err == nil; i-- {
+		decoded, err = decodeBody(decoded, codings[i])
+	}
+	return
+}
+
+// EncodeBody encodes a body by its Content-Encoding header, it is the reverse of DecodeBody
+func EncodeBody(data []byte, contentEncoding string) (encoded []byte, err error) {
+	encoded = data
+	for _, coding := range ParseContentEncoding(contentEncoding) {
+		if encoded, err = encodeBody(encoded, coding); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func encodeBody(data []byte, coding string) (encoded []byte, err error) {
+	buf := new(bytes.Buffer)
+	var writer io.WriteCloser
+	switch coding {
+	case "gzip":
+		writer = gzip.NewWriter(buf)
+	case "deflate":
+		writer = zlib.NewWriter(buf)
+	case "br":
+		writer = brotli.NewWriter(buf)
+	default:
+		return data, fmt.Errorf("unsupported content encoding %q", coding)
+	}
+	if _, err = writer.Write(data); err == nil {
+		err = writer.Close()
+	}
+	encoded = buf.Bytes()
+	return
+}
+
+func decodeBody(data []byte, coding string) (decoded []byte, err error) {
+	var reader io.Reader
+	switch coding {
+	case "gzip":
+		if reader, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			return data, err
+		}
+	case "deflate":
+		// deflate should be a zlib stream, but some servers send the raw deflate data
+		if reader, err = zlib.NewReader(bytes.NewReader(data)); err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(data)), nil
+		}
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(data))
+	default:
+		return data, fmt.Errorf("unsupported content encoding %q", coding)
+	}
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBody(t *testing.T) {
+	const text = `{"name":"rick"}`
+	compress := func(newWriter func(io.Writer) io.WriteCloser, data []byte) []byte {
+		buf := new(bytes.Buffer)
+		writer := newWriter(buf)
+		_, _ = writer.Write(data)
+		_ = writer.Close()
+		return buf.Bytes()
+	}
+	gzipWriter := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	zlibWriter := func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+	flateWriter := func(w io.Writer) io.WriteCloser {
+		writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return writer
+	}
+	brotliWriter := func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }
+
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+	}{{
+		name: "identity",
+		data: []byte(text),
+	}, {
+		name:     "gzip",
+		data:     compress(gzipWriter, []byte(text)),
+		encoding: "gzip",
+	}, {
+		name:     "x-gzip",
+		data:     compress(gzipWriter, []byte(text)),
+		encoding: "X-Gzip",
+	}, {
+		name:     "deflate",
+		data:     compress(zlibWriter, []byte(text)),
+		encoding: "deflate",
+	}, {
+		name:     "raw deflate",
+		data:     compress(flateWriter, []byte(text)),
+		encoding: "deflate",
+	}, {
+		name:     "br",
+		data:     compress(brotliWriter, []byte(text)),
+		encoding: "br",
+	}, {
+		name:     "multiple codings",
+		data:     compress(brotliWriter, compress(gzipWriter, []byte(text))),
+		encoding: "gzip, identity, br",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := pkg.DecodeBody(tt.data, tt.encoding)
+			assert.NoError(t, err)
+			assert.Equal(t, text, string(decoded))
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		data := compress(gzipWriter, bytes.Repeat([]byte(text), 1000))
+		decoded, err := pkg.DecodeBody(data[:len(data)/2], "gzip")
+		assert.Error(t, err)
+		assert.NotEmpty(t, decoded)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		decoded, err := pkg.DecodeBody([]byte(text), "zstd")
+		assert.Error(t, err)
+		assert.Equal(t, text, string(decoded))
+	})
+}
+
+func TestEncodeBody(t *testing.T) {
+	const text = `{"name":"rick"}`
+	for _, encoding := range []string{"", "gzip", "x-gzip", "deflate", "br", "gzip, br"} {
+		t.Run(encoding, func(t *testing.T) {
+			encoded, err := pkg.EncodeBody([]byte(text), encoding)
+			assert.NoError(t, err)
+			decoded, err := pkg.DecodeBody(encoded, encoding)
+			assert.NoError(t, err)
+			assert.Equal(t, text, string(decoded))
+		})
+	}
+
+	_, err := pkg.EncodeBody([]byte(text), "zstd")
+	assert.Error(t, err)
+}
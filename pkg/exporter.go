@@ -1,19 +1,41 @@
 package pkg
 
 import (
+	"encoding/json"
 	"fmt"
-	"io"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/linuxsuren/api-testing/pkg/testing"
 	"gopkg.in/yaml.v3"
 )
 
+// Exporter exports the collected requests
+type Exporter interface {
+	Add(reqAndResp *RequestAndResponse)
+	Export() (string, error)
+}
+
 // SampleExporter is a sample exporter
 type SampleExporter struct {
 	TestSuite        testing.TestSuite
 	saveResponseBody bool
+	binaryMode       BinaryMode
+	headerPolicy     *HeaderPolicy
+	pathParams       bool
+	assertions       bool
+	schemas          *SchemaInferrer
+	schemaDir        string
+	outputDir        string
+	keys             []string
+	chain            *ChainDetector
+	names            map[string]int
+	graphQLErrors    bool
 }
 
 // NewSampleExporter creates a new exporter
@@ -23,24 +45,154 @@ func NewSampleExporter(saveResponseBody bool) *SampleExporter {
 			Name: "sample",
 		},
 		saveResponseBody: saveResponseBody,
+		binaryMode:       BinaryModeSummary,
+		headerPolicy:     NewHeaderPolicy(),
+	}
+}
+
+// WithHeaderPolicy sets the policy of the request headers
+func (e *SampleExporter) WithHeaderPolicy(policy *HeaderPolicy) *SampleExporter {
+	e.headerPolicy = policy
+	return e
+}
+
+// WithBinaryMode sets the way to keep the binary bodies
+func (e *SampleExporter) WithBinaryMode(mode BinaryMode) *SampleExporter {
+	e.binaryMode = mode
+	return e
+}
+
+// WithPathParams replaces the path parameters with the suite parameters,
+// it should work together with the NormalizedKeyStrategy
+func (e *SampleExporter) WithPathParams(pathParams bool) *SampleExporter {
+	e.pathParams = pathParams
+	return e
+}
+
+// WithAssertions generates the assertions of the JSON response bodies
+// instead of the exact matches, the other bodies are not affected
+func (e *SampleExporter) WithAssertions(assertions bool) *SampleExporter {
+	e.assertions = assertions
+	return e
+}
+
+// WithSchemas sets the JSON schemas of the responses as the expectation, the schemas are
+// written into the given directory and referenced by the test cases if it is not empty
+func (e *SampleExporter) WithSchemas(schemas *SchemaInferrer, dir string) *SampleExporter {
+	e.schemas = schemas
+	e.schemaDir = dir
+	return e
+}
+
+// WithOutputDir sets the directory of the output file, the schema files are referenced relative to it
+func (e *SampleExporter) WithOutputDir(dir string) *SampleExporter {
+	e.outputDir = dir
+	return e
+}
+
+// WithChain detects the values of the earlier responses in the later requests,
+// and replaces them with the template references to the earlier test cases
+func (e *SampleExporter) WithChain(chain bool) *SampleExporter {
+	e.chain = nil
+	if chain {
+		e.chain = NewChainDetector()
+		e.names = map[string]int{}
+		for _, item := range e.TestSuite.Items {
+			uniqueName(e.names, item.Name)
+		}
 	}
+	return e
+}
+
+// WithGraphQLErrors expects no errors in the GraphQL responses, then the test cases
+// fail if the errors are returned with the HTTP status 200
+func (e *SampleExporter) WithGraphQLErrors(graphQLErrors bool) *SampleExporter {
+	e.graphQLErrors = graphQLErrors
+	return e
 }
 
 // Add adds a request to the exporter
 func (e *SampleExporter) Add(reqAndResp *RequestAndResponse) {
+	e.AddWithName(reqAndResp, "")
+}
+
+// AddWithName adds a request with the name of its test case, the name is taken from the request if it is empty.
+// The name is given before tracking the response, then the later test cases refer to it.
+func (e *SampleExporter) AddWithName(reqAndResp *RequestAndResponse, name string) {
 	r, resp := reqAndResp.Request, reqAndResp.Response
 
 	log.Println("receive", r.URL.Path)
 	req := testing.Request{
 		API:    r.URL.String(),
 		Method: r.Method,
-		Header: map[string]string{},
+		Header: e.headerPolicy.Apply(r.Header),
 	}
 
-	if body := r.Body; body != nil {
-		if data, err := io.ReadAll(body); err == nil {
+	var template string
+	var params []PathParam
+	if e.pathParams {
+		template, params = e.literalParams(NormalizePath(r.URL.Path))
+	}
+	if e.chain != nil {
+		req.API, params = e.chain.TemplateURL(r.URL, template, params)
+	} else if len(params) > 0 {
+		req.API = templateURL(r.URL, template, params)
+	}
+	if len(params) > 0 {
+		if e.TestSuite.Param == nil {
+			e.TestSuite.Param = map[string]string{}
+		}
+		for _, param := range params {
+			if _, ok := e.TestSuite.Param[param.Name]; !ok {
+				e.TestSuite.Param[param.Name] = param.Value
+			}
+		}
+	}
+
+	graphQL, isGraphQL := ParseGraphQLRequest(reqAndResp)
+	contentEncoding := r.Header.Get("Content-Encoding")
+	if data := reqAndResp.ReadRequestBody(); len(data) > 0 && len(ParseContentEncoding(contentEncoding)) > 0 {
+		// the runner sends the body as it is, then it is encoded again like the original one
+		if body, err := EncodeBody(data, contentEncoding); err == nil {
+			req.Body = EncodeBinaryBody(body, BinaryModeBase64)
+			req.Header["Content-Encoding"] = contentEncoding
+		} else {
+			log.Println("failed to encode the request body of", r.URL.Path, err)
+			req.Body = string(data)
+		}
+	} else if len(data) > 0 {
+		contentType := r.Header.Get("Content-Type")
+		switch GetBodyKind(contentType) {
+		case BodyKindForm:
+			if form, files, err := ParseFormFiles(contentType, data); err == nil && len(files) == 0 {
+				req.Form = form
+				// api-testing encodes the form only if the content type is the bare media type
+				req.Header["Content-Type"] = getMediaType(contentType)
+			} else {
+				if len(files) > 0 {
+					log.Println("keep the multipart body of", r.URL.Path, "because of the uploaded files", files)
+				}
+				if req.Body = string(data); !utf8.Valid(data) {
+					req.Body = EncodeBinaryBody(data, e.binaryMode)
+				}
+			}
+		case BodyKindBinary:
+			if contentType == "" {
+				req.Body = string(data)
+			} else {
+				req.Body = EncodeBinaryBody(data, e.binaryMode)
+			}
+		default:
 			req.Body = string(data)
 		}
+		// the query is kept in one line, and the variables are split out as an object
+		if isGraphQL && r.Method == http.MethodPost {
+			req.Body = graphQL.Body()
+		}
+	}
+
+	if e.chain != nil {
+		e.chainRequest(r, &req)
 	}
 
 	testCase := testing.TestCase{
@@ -49,41 +201,233 @@ func (e *SampleExporter) Add(reqAndResp *RequestAndResponse) {
 
 	if resp != nil {
 		testCase.Expect.StatusCode = resp.StatusCode
-		if e.saveResponseBody && resp.Body != "" {
-			testCase.Expect.Body = resp.Body
+		contentType := resp.Header.Get("Content-Type")
+		if events := resp.Events(); len(events) > 0 {
+			// the complete events are kept even if the stream is interrupted, it is the usual way to stop it
+			if e.saveResponseBody || e.assertions {
+				testCase.Expect.Body = FormatServerSentEvents(events)
+			}
+		} else if resp.Truncated {
+			// the partial body could not be an expectation
+			log.Println("skip the truncated response body of", r.URL.Path)
+		} else if e.assertions && resp.Body != "" && GetBodyKind(contentType) == BodyKindJSON {
+			if assertions, err := GenerateAssertions(resp.Body); err == nil {
+				if len(assertions.BodyFieldsExpect) > 0 {
+					testCase.Expect.BodyFieldsExpect = assertions.BodyFieldsExpect
+				}
+				testCase.Expect.Verify = assertions.Verify
+			} else {
+				log.Println("failed to generate the assertions", err)
+			}
+		} else if e.saveResponseBody && resp.Body != "" {
+			if contentType != "" && GetBodyKind(contentType) == BodyKindBinary {
+				testCase.Expect.Body = EncodeBinaryBody([]byte(resp.Body), e.binaryMode)
+			} else {
+				testCase.Expect.Body = resp.Body
+			}
+		}
+		if isGraphQL && e.graphQLErrors {
+			testCase.Expect.Verify = append(testCase.Expect.Verify, "data.errors == nil")
 		}
 	}
 
 	specs := strings.Split(r.URL.Path, "/")
-	if len(specs) > 0 {
+	if e.pathParams {
+		for len(specs) > 1 && IsPathParam(specs[len(specs)-1]) {
+			specs = specs[:len(specs)-1]
+		}
+	}
+	if name != "" {
+		testCase.Name = name
+	} else if isGraphQL {
+		testCase.Name = graphQL.Name()
+	} else if len(specs) > 0 {
 		testCase.Name = specs[len(specs)-1]
 	}
 
-	if val := r.Header.Get("Content-Type"); val != "" {
-		req.Header["Content-Type"] = val
+	e.TestSuite.Items = append(e.TestSuite.Items, testCase)
+	e.keys = append(e.keys, reqAndResp.Key)
+
+	if e.chain != nil {
+		// the name is the same as the exported one
+		name = uniqueName(e.names, testCase.Name)
+		if resp != nil && GetBodyKind(resp.Header.Get("Content-Type")) == BodyKindJSON {
+			e.chain.Harvest(name, resp.Body)
+		}
 	}
-	if val := r.Header.Get("Authorization"); val != "" {
-		req.Header["Authorization"] = val
+}
+
+// literalParams keeps the values of the params which conflict with the suite params in the template,
+// every param name of the suite has only one value
+func (e *SampleExporter) literalParams(template string, params []PathParam) (string, []PathParam) {
+	var rest []PathParam
+	for _, param := range params {
+		if value, ok := e.TestSuite.Param[param.Name]; ok && value != param.Value {
+			template = strings.Replace(template, "{"+param.Name+"}", param.Value, 1)
+		} else {
+			rest = append(rest, param)
+		}
 	}
+	return template, rest
+}
 
-	e.TestSuite.Items = append(e.TestSuite.Items, testCase)
+// chainRequest replaces the values of the earlier responses in the headers and body. The secret headers
+// are replaced only when the whole value or the bearer token is tracked, otherwise they stay masked
+func (e *SampleExporter) chainRequest(r *http.Request, req *testing.Request) {
+	for name, value := range req.Header {
+		if !e.headerPolicy.IsSecret(name) {
+			req.Header[name] = e.chain.Replace(value)
+			continue
+		}
+
+		raw := strings.Join(r.Header.Values(name), ", ")
+		if name == "Cookie" {
+			raw = strings.Join(r.Header.Values(name), "; ")
+		}
+		if ref, ok := e.chain.ReplaceExact(raw); ok {
+			req.Header[name] = ref
+		} else if scheme, token, ok := strings.Cut(raw, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			if ref, ok := e.chain.ReplaceExact(token); ok {
+				req.Header[name] = scheme + " " + ref
+			}
+		}
+	}
+	for name, value := range req.Form {
+		req.Form[name] = e.chain.Replace(value)
+	}
+	// the encoded binary bodies and the compressed ones are skipped
+	contentType := r.Header.Get("Content-Type")
+	binary := contentType != "" && GetBodyKind(contentType) == BodyKindBinary
+	if req.Body != "" && !binary && len(ParseContentEncoding(r.Header.Get("Content-Encoding"))) == 0 {
+		req.Body = e.chain.ReplaceJSON(req.Body)
+	}
 }
 
 var prefix = testing.GetHeader()
 
 // Export exports the test suite
 func (e *SampleExporter) Export() (string, error) {
+	return e.export(e.exportedItems())
+}
+
+// ExportLatest exports the test suite which has only the newest test case, the references
+// to the earlier test cases and the params are kept
+func (e *SampleExporter) ExportLatest() (string, error) {
+	items := e.exportedItems()
+	if len(items) > 0 {
+		items = items[len(items)-1:]
+	}
+	return e.export(items)
+}
+
+// Latest returns the newest test case with its exported name
+func (e *SampleExporter) Latest() (testCase testing.TestCase, ok bool) {
+	if items := e.exportedItems(); len(items) > 0 {
+		testCase, ok = items[len(items)-1], true
+	}
+	return
+}
+
+// exportedItems returns a copy of the test cases with the unique names
+func (e *SampleExporter) exportedItems() (items []testing.TestCase) {
 	marker := map[string]int{}
+	items = make([]testing.TestCase, len(e.TestSuite.Items))
+	copy(items, e.TestSuite.Items)
+	for i, item := range items {
+		items[i].Name = uniqueName(marker, item.Name)
+	}
+	return
+}
 
-	for i, item := range e.TestSuite.Items {
-		if _, ok := marker[item.Name]; ok {
-			marker[item.Name]++
-			e.TestSuite.Items[i].Name = fmt.Sprintf("%s-%d", item.Name, marker[item.Name])
-		} else {
-			marker[item.Name] = 0
-		}
+func (e *SampleExporter) export(items []testing.TestCase) (string, error) {
+	suite := e.TestSuite
+	suite.Items = items
+	if err := e.setSchemas(suite.Items); err != nil {
+		return "", err
 	}
 
-	data, err := yaml.Marshal(e.TestSuite)
+	data, err := yaml.Marshal(suite)
 	return prefix + string(data), err
 }
+
+// uniqueName returns the name with a number suffix if it has been used
+func uniqueName(marker map[string]int, name string) string {
+	if _, ok := marker[name]; ok {
+		marker[name]++
+		return fmt.Sprintf("%s-%d", name, marker[name])
+	}
+	marker[name] = 0
+	return name
+}
+
+func (e *SampleExporter) setSchemas(items []testing.TestCase) (err error) {
+	if e.schemas == nil {
+		return
+	}
+	if e.schemaDir != "" {
+		if err = os.MkdirAll(e.schemaDir, 0755); err != nil {
+			return
+		}
+	}
+
+	for i := range items {
+		schema := e.schemas.Get(e.keys[i])
+		if schema == nil {
+			continue
+		}
+
+		var data []byte
+		if data, err = json.MarshalIndent(schema, "", "  "); err != nil {
+			return
+		}
+		if e.schemaDir == "" {
+			items[i].Expect.Schema = string(data)
+			continue
+		}
+
+		name := items[i].Name
+		if name == "" {
+			name = "index"
+		}
+
+		file := filepath.Join(e.schemaDir, name+".json")
+		if err = WriteFileAtomic(file, data, 0644); err != nil {
+			return
+		}
+		var ref string
+		if ref, err = e.schemaRef(file); err != nil {
+			return
+		}
+		items[i].Expect.Schema = fmt.Sprintf(`{"$ref": %q}`, ref)
+	}
+	return
+}
+
+// schemaRef returns the file URL of the schema which is relative to the output directory,
+// then the test suite works together with the schemas on the other machines
+func (e *SampleExporter) schemaRef(file string) (ref string, err error) {
+	outputDir := e.outputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	if outputDir, err = filepath.Abs(outputDir); err != nil {
+		return
+	}
+	if file, err = filepath.Abs(file); err != nil {
+		return
+	}
+	if file, err = filepath.Rel(outputDir, file); err != nil {
+		return
+	}
+
+	segments := strings.Split(filepath.ToSlash(file), "/")
+	for i, segment := range segments {
+		// the path is unescaped as a query by the JSON schema loader
+		segments[i] = url.QueryEscape(segment)
+	}
+	if segments[0] != ".." {
+		segments = append([]string{"."}, segments...)
+	}
+	ref = "file://" + strings.Join(segments, "/")
+	return
+}
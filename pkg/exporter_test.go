@@ -2,13 +2,20 @@ package pkg_test
 
 import (
 	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"strings"
 	"testing"
 
 	_ "embed"
 
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
 	"github.com/linuxsuren/atest-ext-collector/pkg"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestSampleExporter(t *testing.T) {
@@ -44,3 +51,134 @@ func newRequest() (request *http.Request, err error) {
 
 //go:embed testdata/sample_suite.yaml
 var sampleSuite string
+
+func TestSampleExporterBodyKinds(t *testing.T) {
+	newReqAndResp := func(contentType, body string, resp *pkg.SimpleResponse) *pkg.RequestAndResponse {
+		request, _ := http.NewRequest(http.MethodPost, "http://foo/api/v1/"+strings.Split(contentType, "/")[1],
+			bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", contentType)
+		return &pkg.RequestAndResponse{Request: request, Response: resp}
+	}
+
+	exporter := pkg.NewSampleExporter(true).WithBinaryMode(pkg.BinaryModeBase64)
+	exporter.Add(newReqAndResp("application/x-www-form-urlencoded", "name=rick", nil))
+	exporter.Add(newReqAndResp("text/xml", "<name>rick</name>", &pkg.SimpleResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/xml"}},
+		Body:       "<ok/>",
+	}))
+	exporter.Add(newReqAndResp("application/x-protobuf", "hello", &pkg.SimpleResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/x-protobuf"}},
+		Body:       "world",
+	}))
+
+	items := exporter.TestSuite.Items
+	assert.Len(t, items, 3)
+	assert.Equal(t, map[string]string{"name": "rick"}, items[0].Request.Form)
+	assert.Empty(t, items[0].Request.Body)
+	assert.Equal(t, "<name>rick</name>", items[1].Request.Body)
+	assert.Equal(t, "<ok/>", items[1].Expect.Body)
+	assert.Equal(t, `{{b64dec "aGVsbG8="}}`, items[2].Request.Body)
+	assert.Equal(t, `{{b64dec "d29ybGQ="}}`, items[2].Expect.Body)
+}
+
+func TestSampleExporterMultipart(t *testing.T) {
+	newRequest := func(path string, file []byte) *http.Request {
+		buf := &bytes.Buffer{}
+		writer := multipart.NewWriter(buf)
+		_ = writer.WriteField("name", "rick")
+		if file != nil {
+			part, _ := writer.CreateFormFile("avatar", "avatar.png")
+			_, _ = part.Write(file)
+		}
+		_ = writer.Close()
+		request, _ := http.NewRequest(http.MethodPost, "http://foo/api/v1/"+path, buf)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		return request
+	}
+
+	exporter := pkg.NewSampleExporter(true).WithBinaryMode(pkg.BinaryModeBase64)
+	exporter.Add(&pkg.RequestAndResponse{Request: newRequest("users", nil)})
+	textRequest := &pkg.RequestAndResponse{Request: newRequest("avatars", []byte("avatar"))}
+	textBody := string(textRequest.ReadRequestBody())
+	exporter.Add(textRequest)
+	exporter.Add(&pkg.RequestAndResponse{Request: newRequest("images", []byte{0x89, 'P', 'N', 'G', 0xff})})
+
+	items := exporter.TestSuite.Items
+	assert.Len(t, items, 3)
+	assert.Equal(t, map[string]string{"name": "rick"}, items[0].Request.Form)
+	assert.Equal(t, "multipart/form-data", items[0].Request.Header["Content-Type"])
+
+	// the runner encodes the form with a new boundary
+	body, err := items[0].Request.GetBody()
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	fields, err := pkg.ParseForm(items[0].Request.Header["Content-Type"], data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "rick"}, fields)
+
+	// the uploaded files are kept in the body
+	assert.Empty(t, items[1].Request.Form)
+	assert.Equal(t, textBody, items[1].Request.Body)
+	assert.Contains(t, items[1].Request.Header["Content-Type"], "boundary=")
+	assert.True(t, strings.HasPrefix(items[2].Request.Body, `{{b64dec "`))
+}
+
+func TestSampleExporterContentEncoding(t *testing.T) {
+	const body = `{"name":"rick"}`
+	request, _ := http.NewRequest(http.MethodPost, "http://foo/api/v1/users", bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Content-Encoding", "gzip")
+	exporter := pkg.NewSampleExporter(true).WithChain(true)
+	exporter.Add(&pkg.RequestAndResponse{Request: request})
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+	suite := &atest.TestSuite{}
+	assert.NoError(t, yaml.Unmarshal([]byte(result), suite))
+	item := suite.Items[0]
+	assert.Equal(t, "gzip", item.Request.Header["Content-Encoding"])
+
+	// the body is sent with the same encoding as the recorded one
+	var encoded string
+	_, err = fmt.Sscanf(item.Request.Body, `{{b64dec %q}}`, &encoded)
+	assert.NoError(t, err)
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	assert.NoError(t, err)
+	decoded, err := pkg.DecodeBody(data, item.Request.Header["Content-Encoding"])
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+}
+
+func TestSampleExporterWithPathParams(t *testing.T) {
+	collects := pkg.NewCollects()
+	collects.SetKeyStrategy(&pkg.NormalizedKeyStrategy{})
+	exporter := pkg.NewSampleExporter(false).WithPathParams(true)
+
+	done := make(chan struct{})
+	collects.AddEvent(func(r *pkg.RequestAndResponse) {
+		exporter.Add(r)
+		done <- struct{}{}
+	})
+	defer collects.Stop()
+
+	for _, api := range []string{"http://foo/users/1?a=b", "http://foo/users/2?a=b", "http://foo/users/1/orders/3",
+		"http://foo/users/2/carts/4"} {
+		request, _ := http.NewRequest(http.MethodGet, api, nil)
+		collects.Add(request, nil)
+	}
+	<-done
+	<-done
+	<-done
+
+	items := exporter.TestSuite.Items
+	assert.Len(t, items, 3)
+	assert.Equal(t, "users", items[0].Name)
+	assert.Equal(t, "http://foo/users/{{.param.userId}}?a=b", items[0].Request.API)
+	assert.Equal(t, "orders", items[1].Name)
+	assert.Equal(t, "http://foo/users/{{.param.userId}}/orders/{{.param.orderId}}", items[1].Request.API)
+	// the conflicting value is kept as it is
+	assert.Equal(t, "http://foo/users/2/carts/{{.param.cartId}}", items[2].Request.API)
+	assert.Equal(t, map[string]string{"userId": "1", "orderId": "3", "cartId": "4"}, exporter.TestSuite.Param)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a filter in the YAML file. The fields of a rule are combined by AND, the values of a field are
+// combined by OR, and the request is excluded if any of the exclude rules matches. An empty rule matches everything.
+//
+//	host: [api.example.com]
+//	path: [/v2/**]
+//	exclude:
+//	  - path: [/v2/health]
+//	  - method: [GET]
+type Rule struct {
+	Host       []string `yaml:"host" json:"host,omitempty"`
+	PathPrefix []string `yaml:"pathPrefix" json:"pathPrefix,omitempty"`
+	Path       []string `yaml:"path" json:"path,omitempty"`
+	PathRegex  []string `yaml:"pathRegex" json:"pathRegex,omitempty"`
+	Method     []string `yaml:"method" json:"method,omitempty"`
+	Status     []string `yaml:"status" json:"status,omitempty"`
+	And        []Rule   `yaml:"and" json:"and,omitempty"`
+	Or         []Rule   `yaml:"or" json:"or,omitempty"`
+	Not        *Rule    `yaml:"not" json:"not,omitempty"`
+	Exclude    []Rule   `yaml:"exclude" json:"exclude,omitempty"`
+}
+
+// ParseFile reads the filter rule from a YAML file
+func ParseFile(file string) (f Filter, err error) {
+	var data []byte
+	if data, err = os.ReadFile(file); err == nil {
+		f, err = Parse(data)
+	}
+	return
+}
+
+// Parse parses the filter rule from YAML
+func Parse(data []byte) (f Filter, err error) {
+	// the typos of the fields must be rejected, or the rule matches everything
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	rule := Rule{}
+	if err = decoder.Decode(&rule); err == nil || err == io.EOF {
+		f, err = rule.Build()
+	}
+	return
+}
+
+// Build creates the filter of the rule
+func (r Rule) Build() (f Filter, err error) {
+	filters := And{}
+	add := func(item Filter, err error) error {
+		if err == nil {
+			filters = append(filters, item)
+		}
+		return err
+	}
+
+	if len(r.Host) > 0 {
+		err = add(NewHostFilter(r.Host...))
+	}
+	if err == nil && len(r.PathPrefix) > 0 {
+		err = add(&URLPathFilter{PathPrefix: r.PathPrefix}, nil)
+	}
+	if err == nil && len(r.Path) > 0 {
+		err = add(NewPathFilter(r.Path...))
+	}
+	if err == nil && len(r.PathRegex) > 0 {
+		err = add(NewPathRegexFilter(r.PathRegex...))
+	}
+	if err == nil && len(r.Method) > 0 {
+		err = add(&MethodFilter{Methods: r.Method}, nil)
+	}
+	if err == nil && len(r.Status) > 0 {
+		err = add(NewStatusFilter(r.Status...))
+	}
+	for _, rule := range r.And {
+		if err == nil {
+			err = add(rule.Build())
+		}
+	}
+	if err == nil && len(r.Or) > 0 {
+		var or Or
+		if or, err = buildAll(r.Or); err == nil {
+			filters = append(filters, or)
+		}
+	}
+	if err == nil && r.Not != nil {
+		var not Filter
+		if not, err = r.Not.Build(); err == nil {
+			filters = append(filters, &Not{Filter: not})
+		}
+	}
+	if err == nil && len(r.Exclude) > 0 {
+		var excludes Or
+		if excludes, err = buildAll(r.Exclude); err == nil {
+			filters = append(filters, &Not{Filter: excludes})
+		}
+	}
+
+	if err == nil {
+		f = filters
+	}
+	return
+}
+
+func buildAll(rules []Rule) (filters []Filter, err error) {
+	for _, rule := range rules {
+		var f Filter
+		if f, err = rule.Build(); err != nil {
+			return
+		}
+		filters = append(filters, f)
+	}
+	return
+}
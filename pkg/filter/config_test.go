@@ -0,0 +1,72 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "filter.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+host: [api.example.com]
+path: [/v2/**]
+exclude:
+  - path: [/v2/health]
+  - method: [GET]
+`), 0644))
+	requestFilter, err := filter.ParseFile(file)
+	assert.NoError(t, err)
+	assert.True(t, requestFilter.Match(exchange(http.MethodPost, "http://api.example.com/v2/users", 201)))
+	assert.False(t, requestFilter.Match(exchange(http.MethodGet, "http://api.example.com/v2/users", 200)))
+	assert.False(t, requestFilter.Match(exchange(http.MethodPost, "http://api.example.com/v2/health", 200)))
+	assert.False(t, requestFilter.Match(exchange(http.MethodPost, "http://example.com/v2/users", 200)))
+	assert.False(t, requestFilter.Match(exchange(http.MethodPost, "http://api.example.com/v1/users", 200)))
+
+	requestFilter, err = filter.Parse([]byte(`
+or:
+  - pathPrefix: [/api]
+    status: [2xx]
+  - pathRegex: ['^/graphql$']
+not:
+  method: [OPTIONS]
+`))
+	assert.NoError(t, err)
+	assert.True(t, requestFilter.Match(exchange(http.MethodGet, "http://foo/api/users", 200)))
+	assert.False(t, requestFilter.Match(exchange(http.MethodGet, "http://foo/api/users", 500)))
+	assert.True(t, requestFilter.Match(exchange(http.MethodPost, "http://foo/graphql", 500)))
+	assert.False(t, requestFilter.Match(exchange(http.MethodOptions, "http://foo/graphql", 200)))
+	assert.False(t, requestFilter.Match(exchange(http.MethodGet, "http://foo/users", 200)))
+
+	requestFilter, err = filter.Parse(nil)
+	assert.NoError(t, err)
+	assert.True(t, requestFilter.Match(exchange(http.MethodGet, "http://foo/users", 200)))
+
+	for _, data := range []string{"fake", "status: [fake]", "and:\n  - pathRegex: ['(']", "not:\n  status: [x]",
+		"exclude:\n  - pathRegex: ['(']", "pathprefix: [/api]", "methods: [GET]", "not:\n  hosts: [foo]"} {
+		_, err = filter.Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+	_, err = filter.ParseFile("fake.yaml")
+	assert.Error(t, err)
+}
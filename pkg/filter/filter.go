@@ -0,0 +1,259 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Exchange is a request and its response to filter, the status code is zero before the response arrives
+type Exchange struct {
+	Method     string
+	URL        *url.URL
+	StatusCode int
+}
+
+// Filter is the interface of the composable filters
+type Filter interface {
+	Match(exchange *Exchange) bool
+}
+
+// Match implements the Filter
+func (f *URLPathFilter) Match(exchange *Exchange) bool {
+	return f.Filter(exchange.URL)
+}
+
+// HostFilter matches the host with the glob patterns, for example: *.example.com.
+// The pattern matches the host with the port if it has one, otherwise the port is ignored.
+type HostFilter struct {
+	patterns []*regexp.Regexp
+	withPort []bool
+}
+
+// NewHostFilter creates a host filter, * matches any characters except the dot, ** matches any characters
+func NewHostFilter(patterns ...string) (f *HostFilter, err error) {
+	f = &HostFilter{}
+	for _, pattern := range patterns {
+		var reg *regexp.Regexp
+		if reg, err = compileGlob(strings.ToLower(pattern), '.'); err != nil {
+			return
+		}
+		f.patterns = append(f.patterns, reg)
+		f.withPort = append(f.withPort, strings.Contains(pattern, ":"))
+	}
+	return
+}
+
+// Match implements the Filter
+func (f *HostFilter) Match(exchange *Exchange) bool {
+	for i, reg := range f.patterns {
+		host := exchange.URL.Hostname()
+		if f.withPort[i] {
+			host = exchange.URL.Host
+		}
+		if reg.MatchString(strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
+
+// PathFilter matches the path with the glob patterns, for example: /v2/**
+type PathFilter struct {
+	patterns []*regexp.Regexp
+}
+
+// NewPathFilter creates a path filter, * matches any characters except the slash, ** matches any characters
+func NewPathFilter(patterns ...string) (f *PathFilter, err error) {
+	f = &PathFilter{}
+	for _, pattern := range patterns {
+		var reg *regexp.Regexp
+		if reg, err = compileGlob(pattern, '/'); err != nil {
+			return
+		}
+		f.patterns = append(f.patterns, reg)
+	}
+	return
+}
+
+// Match implements the Filter
+func (f *PathFilter) Match(exchange *Exchange) bool {
+	return matchAny(f.patterns, exchange.URL.Path)
+}
+
+// PathRegexFilter matches the path with the regular expressions
+type PathRegexFilter struct {
+	patterns []*regexp.Regexp
+}
+
+// NewPathRegexFilter creates a regex path filter, the expressions are not anchored
+func NewPathRegexFilter(patterns ...string) (f *PathRegexFilter, err error) {
+	f = &PathRegexFilter{}
+	for _, pattern := range patterns {
+		var reg *regexp.Regexp
+		if reg, err = regexp.Compile(pattern); err != nil {
+			return
+		}
+		f.patterns = append(f.patterns, reg)
+	}
+	return
+}
+
+// Match implements the Filter
+func (f *PathRegexFilter) Match(exchange *Exchange) bool {
+	return matchAny(f.patterns, exchange.URL.Path)
+}
+
+// MethodFilter matches the HTTP methods, the case is ignored
+type MethodFilter struct {
+	Methods []string
+}
+
+// Match implements the Filter
+func (f *MethodFilter) Match(exchange *Exchange) bool {
+	for _, method := range f.Methods {
+		if strings.EqualFold(method, exchange.Method) {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusFilter matches the status codes of the responses. It matches all the exchanges which have no response yet,
+// then the requests could be filtered before the responses arrive.
+type StatusFilter struct {
+	ranges [][2]int
+}
+
+// NewStatusFilter creates a status filter, the codes could be a code (200), a class (2xx) or a range (400-499)
+func NewStatusFilter(codes ...string) (f *StatusFilter, err error) {
+	f = &StatusFilter{}
+	for _, code := range codes {
+		var from, to int
+		code = strings.ToLower(strings.TrimSpace(code))
+		if start, end, ok := strings.Cut(code, "-"); ok {
+			from, err = strconv.Atoi(start)
+			if err == nil {
+				to, err = strconv.Atoi(end)
+			}
+		} else if class := strings.TrimSuffix(code, "xx"); len(class) == 1 && class != code {
+			from, err = strconv.Atoi(class)
+			from, to = from*100, from*100+99
+		} else {
+			from, err = strconv.Atoi(code)
+			to = from
+		}
+		if err != nil || from > to {
+			err = fmt.Errorf("invalid status code %q, it should be a code, class or range, for example: 200, 2xx, 400-499", code)
+			return
+		}
+		f.ranges = append(f.ranges, [2]int{from, to})
+	}
+	return
+}
+
+// Match implements the Filter
+func (f *StatusFilter) Match(exchange *Exchange) bool {
+	if exchange.StatusCode == 0 {
+		return true
+	}
+	for _, r := range f.ranges {
+		if exchange.StatusCode >= r[0] && exchange.StatusCode <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// And matches if all the filters match, it matches everything if it is empty
+type And []Filter
+
+// Match implements the Filter
+func (f And) Match(exchange *Exchange) bool {
+	for _, item := range f {
+		if !item.Match(exchange) {
+			return false
+		}
+	}
+	return true
+}
+
+// Or matches if any of the filters matches, it matches nothing if it is empty
+type Or []Filter
+
+// Match implements the Filter
+func (f Or) Match(exchange *Exchange) bool {
+	for _, item := range f {
+		if item.Match(exchange) {
+			return true
+		}
+	}
+	return false
+}
+
+// Not matches if the filter does not match
+type Not struct {
+	Filter Filter
+}
+
+// Match implements the Filter
+func (f *Not) Match(exchange *Exchange) bool {
+	return !f.Filter.Match(exchange)
+}
+
+// URLFunc adapts the filter to the URL only callers, like the WebSocket handler, the method is GET
+func URLFunc(f Filter) func(*url.URL) bool {
+	return func(u *url.URL) bool {
+		return f.Match(&Exchange{Method: "GET", URL: u})
+	}
+}
+
+// compileGlob converts a glob pattern to an anchored regular expression, * does not match the separator
+func compileGlob(pattern string, separator byte) (*regexp.Regexp, error) {
+	builder := strings.Builder{}
+	builder.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				builder.WriteString(".*")
+				i++
+			} else {
+				builder.WriteString("[^" + regexp.QuoteMeta(string(separator)) + "]*")
+			}
+		case '?':
+			builder.WriteString("[^" + regexp.QuoteMeta(string(separator)) + "]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	builder.WriteString("$")
+	return regexp.Compile(builder.String())
+}
+
+func matchAny(patterns []*regexp.Regexp, text string) bool {
+	for _, reg := range patterns {
+		if reg.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+func exchange(method, rawURL string, statusCode int) *filter.Exchange {
+	u, _ := url.Parse(rawURL)
+	return &filter.Exchange{Method: method, URL: u, StatusCode: statusCode}
+}
+
+func TestHostFilter(t *testing.T) {
+	hostFilter, err := filter.NewHostFilter("*.example.com", "localhost:8080")
+	assert.NoError(t, err)
+	assert.True(t, hostFilter.Match(exchange(http.MethodGet, "http://api.Example.com/users", 0)))
+	assert.True(t, hostFilter.Match(exchange(http.MethodGet, "http://api.example.com:8443/users", 0)))
+	assert.False(t, hostFilter.Match(exchange(http.MethodGet, "http://a.b.example.com/users", 0)))
+	assert.False(t, hostFilter.Match(exchange(http.MethodGet, "http://example.com/users", 0)))
+	assert.True(t, hostFilter.Match(exchange(http.MethodGet, "http://localhost:8080/users", 0)))
+	assert.False(t, hostFilter.Match(exchange(http.MethodGet, "http://localhost:9090/users", 0)))
+}
+
+func TestPathFilter(t *testing.T) {
+	pathFilter, err := filter.NewPathFilter("/v2/**", "/users/*/orders", "/item?")
+	assert.NoError(t, err)
+	assert.True(t, pathFilter.Match(exchange(http.MethodGet, "http://foo/v2/users/1", 0)))
+	assert.False(t, pathFilter.Match(exchange(http.MethodGet, "http://foo/v1/users", 0)))
+	assert.True(t, pathFilter.Match(exchange(http.MethodGet, "http://foo/users/1/orders", 0)))
+	assert.False(t, pathFilter.Match(exchange(http.MethodGet, "http://foo/users/1/2/orders", 0)))
+	assert.True(t, pathFilter.Match(exchange(http.MethodGet, "http://foo/items", 0)))
+	assert.False(t, pathFilter.Match(exchange(http.MethodGet, "http://foo/item/", 0)))
+
+	regexFilter, err := filter.NewPathRegexFilter(`^/users/\d+$`)
+	assert.NoError(t, err)
+	assert.True(t, regexFilter.Match(exchange(http.MethodGet, "http://foo/users/12", 0)))
+	assert.False(t, regexFilter.Match(exchange(http.MethodGet, "http://foo/users/rick", 0)))
+	_, err = filter.NewPathRegexFilter(`(`)
+	assert.Error(t, err)
+}
+
+func TestMethodAndStatusFilter(t *testing.T) {
+	methodFilter := &filter.MethodFilter{Methods: []string{"post", "PUT"}}
+	assert.True(t, methodFilter.Match(exchange(http.MethodPost, "http://foo", 0)))
+	assert.False(t, methodFilter.Match(exchange(http.MethodGet, "http://foo", 0)))
+
+	statusFilter, err := filter.NewStatusFilter("2xx", "404", "500-503")
+	assert.NoError(t, err)
+	for code, expected := range map[int]bool{0: true, 201: true, 302: false, 404: true, 405: false, 502: true, 504: false} {
+		assert.Equal(t, expected, statusFilter.Match(exchange(http.MethodGet, "http://foo", code)), code)
+	}
+	for _, code := range []string{"fake", "2x", "503-500", "xx"} {
+		_, err = filter.NewStatusFilter(code)
+		assert.Error(t, err, code)
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	api := &filter.URLPathFilter{PathPrefix: []string{"/api"}}
+	get := &filter.MethodFilter{Methods: []string{http.MethodGet}}
+	target := exchange(http.MethodPost, "http://foo/api/users", 0)
+
+	assert.True(t, filter.And{}.Match(target))
+	assert.False(t, filter.Or{}.Match(target))
+	assert.True(t, filter.And{api, &filter.Not{Filter: get}}.Match(target))
+	assert.False(t, filter.And{api, get}.Match(target))
+	assert.True(t, filter.Or{api, get}.Match(target))
+	assert.True(t, filter.URLFunc(filter.And{api, get})(target.URL))
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// GraphQLRequest is the body of a GraphQL request
+type GraphQLRequest struct {
+	OperationName string                 `json:"operationName,omitempty"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+var (
+	graphQLOperation = regexp.MustCompile(`^(query|mutation|subscription)\s*([_A-Za-z][_0-9A-Za-z]*)?`)
+	graphQLField     = regexp.MustCompile(`^\{\s*(?:[_A-Za-z][_0-9A-Za-z]*\s*:\s*)?([_A-Za-z][_0-9A-Za-z]*)`)
+	graphQLSpaces    = regexp.MustCompile(`\s+`)
+)
+
+// ParseGraphQLRequest parses the GraphQL request from the JSON body of POST, or the query of GET.
+// It returns false if it is not a GraphQL request.
+func ParseGraphQLRequest(reqAndResp *RequestAndResponse) (request *GraphQLRequest, ok bool) {
+	r := reqAndResp.Request
+	request = &GraphQLRequest{}
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			_ = json.Unmarshal([]byte(variables), &request.Variables)
+		}
+	case http.MethodPost:
+		if GetBodyKind(r.Header.Get("Content-Type")) != BodyKindJSON {
+			return
+		}
+		// the numbers of the variables are kept as they are
+		decoder := json.NewDecoder(bytes.NewReader(reqAndResp.ReadRequestBody()))
+		decoder.UseNumber()
+		if decoder.Decode(request) != nil {
+			return
+		}
+	}
+
+	query := strings.TrimSpace(request.Query)
+	ok = strings.HasPrefix(query, "{") || strings.HasPrefix(query, "fragment") || graphQLOperation.MatchString(query)
+	return
+}
+
+// Name returns the operation name, or the first field of the anonymous operation
+func (r *GraphQLRequest) Name() string {
+	if r.OperationName != "" {
+		return r.OperationName
+	}
+
+	query := strings.TrimSpace(r.Query)
+	// the fragments might be defined before the operation
+	for strings.HasPrefix(query, "fragment") {
+		query = strings.TrimSpace(skipGraphQLBlock(query))
+	}
+	if matches := graphQLOperation.FindStringSubmatch(query); matches != nil {
+		if matches[2] != "" {
+			return matches[2]
+		}
+		if index := strings.Index(query, "{"); index >= 0 {
+			query = query[index:]
+		}
+	}
+	if matches := graphQLField.FindStringSubmatch(query); matches != nil {
+		return matches[1]
+	}
+	return "graphql"
+}
+
+// Hash returns a short checksum of the query, the spaces are ignored
+func (r *GraphQLRequest) Hash() string {
+	sum := sha256.Sum256([]byte(NormalizeGraphQLQuery(r.Query)))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// Body returns the JSON body which keeps the query in one line and the variables as an object
+func (r *GraphQLRequest) Body() string {
+	body := *r
+	body.Query = NormalizeGraphQLQuery(r.Query)
+	data, _ := json.MarshalIndent(body, "", "  ")
+	return string(data)
+}
+
+// NormalizeGraphQLQuery collapses the spaces of a query
+func NormalizeGraphQLQuery(query string) string {
+	return graphQLSpaces.ReplaceAllString(strings.TrimSpace(query), " ")
+}
+
+// skipGraphQLBlock returns the rest of the query after the first balanced braces
+func skipGraphQLBlock(query string) string {
+	depth := 0
+	for i, c := range query {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return query[i+1:]
+			}
+		}
+	}
+	return ""
+}
+
+// GraphQLKeyStrategy deduplicates the GraphQL requests by the operation name and the query,
+// the variables are ignored. The other requests are deduplicated by the fallback strategy.
+type GraphQLKeyStrategy struct {
+	Fallback KeyStrategy
+}
+
+// Key implements the KeyStrategy
+func (s *GraphQLKeyStrategy) Key(reqAndResp *RequestAndResponse) string {
+	if request, ok := ParseGraphQLRequest(reqAndResp); ok {
+		u := reqAndResp.Request.URL
+		return fmt.Sprintf("GRAPHQL-%s://%s%s#%s#%s", u.Scheme, u.Host, u.Path, request.Name(), request.Hash())
+	}
+
+	fallback := s.Fallback
+	if fallback == nil {
+		fallback = &URLKeyStrategy{}
+	}
+	return fallback.Key(reqAndResp)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func newGraphQLRequest(body string) *pkg.RequestAndResponse {
+	request, _ := http.NewRequest(http.MethodPost, "http://foo/graphql", bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json")
+	return &pkg.RequestAndResponse{Request: request}
+}
+
+func TestParseGraphQLRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		graphQL bool
+		op      string
+	}{{
+		name:    "named operation",
+		body:    `{"query": "query GetUser($id: ID!) { user(id: $id) { name } }", "variables": {"id": "1"}}`,
+		graphQL: true,
+		op:      "GetUser",
+	}, {
+		name:    "operation name",
+		body:    `{"operationName": "B", "query": "query A { a } query B { b }"}`,
+		graphQL: true,
+		op:      "B",
+	}, {
+		name:    "anonymous operation",
+		body:    `{"query": "mutation ($name: String) {\n  me: createUser(name: $name) { id }\n}"}`,
+		graphQL: true,
+		op:      "createUser",
+	}, {
+		name:    "shorthand with fragments",
+		body:    `{"query": "fragment f on User { name } { viewer { ...f } }"}`,
+		graphQL: true,
+		op:      "viewer",
+	}, {
+		name: "not a query",
+		body: `{"query": "select * from users"}`,
+	}, {
+		name: "not JSON",
+		body: `query { viewer }`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, ok := pkg.ParseGraphQLRequest(newGraphQLRequest(tt.body))
+			assert.Equal(t, tt.graphQL, ok)
+			if ok {
+				assert.Equal(t, tt.op, request.Name())
+			}
+		})
+	}
+
+	get, _ := http.NewRequest(http.MethodGet, `http://foo/graphql?query={viewer{name}}&variables={"id":1}`, nil)
+	request, ok := pkg.ParseGraphQLRequest(&pkg.RequestAndResponse{Request: get})
+	assert.True(t, ok)
+	assert.Equal(t, "viewer", request.Name())
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, request.Variables)
+
+	request = &pkg.GraphQLRequest{Query: "{ a { b } }"}
+	assert.Equal(t, request.Hash(), (&pkg.GraphQLRequest{Query: "{\n  a {\n    b\n  }\n}"}).Hash())
+	assert.NotEqual(t, request.Hash(), (&pkg.GraphQLRequest{Query: "{ a { c } }"}).Hash())
+	assert.Equal(t, "graphql", (&pkg.GraphQLRequest{Query: "fragment f on User { name }"}).Name())
+}
+
+func TestGraphQLKeyStrategy(t *testing.T) {
+	strategy := &pkg.GraphQLKeyStrategy{}
+	getUser := strategy.Key(newGraphQLRequest(`{"query": "query GetUser { user(id: 1) { name } }", "variables": {"id": 1}}`))
+	assert.Equal(t, getUser, strategy.Key(newGraphQLRequest(`{"query": "query GetUser {\n  user(id: 1) { name }\n}", "variables": {"id": 2}}`)))
+	assert.NotEqual(t, getUser, strategy.Key(newGraphQLRequest(`{"query": "query GetUser { user(id: 1) { id } }"}`)))
+	assert.NotEqual(t, getUser, strategy.Key(newGraphQLRequest(`{"query": "query ListUsers { users { name } }"}`)))
+	assert.Contains(t, getUser, "GRAPHQL-http://foo/graphql#GetUser#")
+
+	other := newGraphQLRequest(`{"name": "rick"}`)
+	assert.Equal(t, "POST-http://foo/graphql", strategy.Key(other))
+	strategy.Fallback = &pkg.NormalizedKeyStrategy{BodyFingerprint: true}
+	assert.Contains(t, strategy.Key(other), "POST-http://foo/graphql#")
+}
+
+func TestSampleExporterWithGraphQL(t *testing.T) {
+	exporter := pkg.NewSampleExporter(true).WithGraphQLErrors(true)
+	for _, body := range []string{
+		`{"query": "query GetUser($id: ID!) {\n  user(id: $id) {\n    name\n  }\n}", "variables": {"id": 12345678901234567890}}`,
+		`{"query": "query ListUsers { users { name } }"}`,
+	} {
+		reqAndResp := newGraphQLRequest(body)
+		reqAndResp.Response = &pkg.SimpleResponse{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       `{"errors": [{"message": "forbidden"}]}`,
+		}
+		exporter.Add(reqAndResp)
+	}
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+
+	suite := &atest.TestSuite{}
+	assert.NoError(t, yaml.Unmarshal([]byte(result), suite))
+	if assert.Len(t, suite.Items, 2) {
+		assert.Equal(t, "GetUser", suite.Items[0].Name)
+		assert.Equal(t, `{
+  "query": "query GetUser($id: ID!) { user(id: $id) { name } }",
+  "variables": {
+    "id": 12345678901234567890
+  }
+}`, suite.Items[0].Request.Body)
+		assert.Equal(t, []string{"data.errors == nil"}, suite.Items[0].Expect.Verify)
+		assert.Equal(t, "ListUsers", suite.Items[1].Name)
+	}
+}
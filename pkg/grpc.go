@@ -0,0 +1,122 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"log"
+	"net"
+	"strings"
+
+	"github.com/linuxsuren/api-testing/pkg/testing"
+	"gopkg.in/yaml.v3"
+)
+
+// grpcTransportHeaders are the headers of the gRPC protocol, they are not the metadata of the calls
+var grpcTransportHeaders = []string{"Content-Type", "Te", "User-Agent", "Grpc-*"}
+
+// GRPCExporter exports the decoded gRPC calls as an api-testing gRPC test suite, the request
+// and response bodies are the JSON messages, and the streaming messages are JSON arrays.
+type GRPCExporter struct {
+	TestSuite        testing.TestSuite
+	saveResponseBody bool
+	headerPolicy     *HeaderPolicy
+}
+
+// NewGRPCExporter creates a gRPC exporter, the descriptors of the suite are the same as the collector's
+func NewGRPCExporter(target string, rpc *testing.RPCDesc, saveResponseBody bool) *GRPCExporter {
+	return &GRPCExporter{
+		TestSuite: testing.TestSuite{
+			Name: "sample",
+			API:  target,
+			Spec: testing.APISpec{
+				Kind: "grpc",
+				RPC:  rpc,
+			},
+		},
+		saveResponseBody: saveResponseBody,
+		headerPolicy:     NewHeaderPolicy(),
+	}
+}
+
+// WithHeaderPolicy sets the policy of the metadata, the gRPC transport headers are always dropped
+func (e *GRPCExporter) WithHeaderPolicy(policy *HeaderPolicy) *GRPCExporter {
+	e.headerPolicy = policy
+	return e
+}
+
+// Add adds a gRPC call, the path of the request is like /server.Runner/GetVersion
+func (e *GRPCExporter) Add(reqAndResp *RequestAndResponse) {
+	r, resp := reqAndResp.Request, reqAndResp.Response
+	if e.TestSuite.API == "" {
+		// the suite of the intercepted calls targets the server of the first one
+		e.TestSuite.API = r.URL.Host
+		if r.URL.Port() == "" && r.URL.Scheme == "https" {
+			e.TestSuite.API = net.JoinHostPort(r.URL.Hostname(), "443")
+		}
+	}
+	policy := *e.headerPolicy
+	policy.Drop = append(append([]string{}, policy.Drop...), grpcTransportHeaders...)
+
+	testCase := testing.TestCase{
+		Request: testing.Request{
+			API:    r.URL.Path,
+			Header: policy.Apply(r.Header),
+			Body:   string(reqAndResp.ReadRequestBody()),
+		},
+	}
+	if len(testCase.Request.Header) == 0 {
+		testCase.Request.Header = nil
+	}
+	if index := strings.LastIndex(r.URL.Path, "/"); index >= 0 {
+		testCase.Name = r.URL.Path[index+1:]
+	}
+
+	if resp != nil {
+		// the status is kept for reference, the runner fails the test case if it is not OK
+		status := resp.Header.Get("Grpc-Status")
+		if status == "" {
+			status = "0"
+		}
+		testCase.Expect.Header = map[string]string{"grpc-status": status}
+		if message := resp.Header.Get("Grpc-Message"); message != "" {
+			testCase.Expect.Header["grpc-message"] = message
+		}
+		if resp.Truncated {
+			// the partial messages could not be an expectation
+			log.Println("skip the truncated response body of", r.URL.Path)
+		} else if e.saveResponseBody && resp.Body != "" {
+			testCase.Expect.Body = resp.Body
+		}
+	}
+
+	e.TestSuite.Items = append(e.TestSuite.Items, testCase)
+}
+
+// Export exports the gRPC test suite
+func (e *GRPCExporter) Export() (string, error) {
+	marker := map[string]int{}
+
+	suite := e.TestSuite
+	suite.Items = make([]testing.TestCase, len(e.TestSuite.Items))
+	copy(suite.Items, e.TestSuite.Items)
+	for i, item := range suite.Items {
+		suite.Items[i].Name = uniqueName(marker, item.Name)
+	}
+
+	data, err := yaml.Marshal(suite)
+	return prefix + string(data), err
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGRPCExporter(t *testing.T) {
+	newReqAndResp := func(path, body string, trailer http.Header) *pkg.RequestAndResponse {
+		request, _ := http.NewRequest(http.MethodPost, "http://localhost:7070"+path, bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", "application/grpc")
+		request.Header.Set("Te", "trailers")
+		request.Header.Set("Grpc-Timeout", "1S")
+		request.Header.Set("User-Agent", "grpc-go/1.60.0")
+		request.Header.Set("Authorization", "Bearer token")
+		request.Header.Set("X-Tenant", "dev")
+		return &pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+			StatusCode: http.StatusOK,
+			Header:     trailer,
+			Body:       `{"message": "hello"}`,
+		}}
+	}
+
+	exporter := pkg.NewGRPCExporter("localhost:7070", &atest.RPCDesc{
+		ImportPath: []string{"proto"},
+		ProtoFile:  "hello.proto",
+	}, true)
+	exporter.Add(newReqAndResp("/sample.Greeter/SayHello", `{"name": "rick"}`, http.Header{"Grpc-Status": {"0"}}))
+	exporter.Add(newReqAndResp("/sample.Greeter/SayHello", `{"name": ""}`,
+		http.Header{"Grpc-Status": {"3"}, "Grpc-Message": {"name is required"}}))
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+
+	suite := &atest.TestSuite{}
+	assert.NoError(t, yaml.Unmarshal([]byte(result), suite))
+	assert.Equal(t, "localhost:7070", suite.API)
+	assert.Equal(t, "grpc", suite.Spec.Kind)
+	assert.Equal(t, []string{"proto"}, suite.Spec.RPC.ImportPath)
+	assert.Equal(t, "hello.proto", suite.Spec.RPC.ProtoFile)
+	if assert.Len(t, suite.Items, 2) {
+		assert.Equal(t, "SayHello", suite.Items[0].Name)
+		assert.Equal(t, "/sample.Greeter/SayHello", suite.Items[0].Request.API)
+		assert.Equal(t, `{"name": "rick"}`, suite.Items[0].Request.Body)
+		assert.Equal(t, map[string]string{
+			"Authorization": `{{env "AUTHORIZATION"}}`,
+			"X-Tenant":      "dev",
+		}, suite.Items[0].Request.Header)
+		assert.Equal(t, `{"message": "hello"}`, suite.Items[0].Expect.Body)
+		assert.Equal(t, map[string]string{"grpc-status": "0"}, suite.Items[0].Expect.Header)
+
+		assert.Equal(t, "SayHello-1", suite.Items[1].Name)
+		assert.Equal(t, map[string]string{"grpc-status": "3", "grpc-message": "name is required"}, suite.Items[1].Expect.Header)
+	}
+
+	// the status is OK if there is no trailer
+	exporter = pkg.NewGRPCExporter("localhost:7070", nil, false)
+	exporter.Add(newReqAndResp("/sample.Greeter/SayHello", `{}`, http.Header{}))
+	assert.Equal(t, map[string]string{"grpc-status": "0"}, exporter.TestSuite.Items[0].Expect.Header)
+	assert.Empty(t, exporter.TestSuite.Items[0].Expect.Body)
+
+	// the truncated messages are not the expectation
+	exporter = pkg.NewGRPCExporter("localhost:7070", nil, true)
+	truncated := newReqAndResp("/sample.Greeter/ListHellos", `{}`, http.Header{})
+	truncated.Response.Truncated = true
+	exporter.Add(truncated)
+	assert.Empty(t, exporter.TestSuite.Items[0].Expect.Body)
+
+	// the intercepted calls target the server of the first one
+	exporter = pkg.NewGRPCExporter("", nil, false)
+	intercepted := newReqAndResp("/sample.Greeter/SayHello", `{}`, http.Header{})
+	intercepted.Request.URL.Scheme, intercepted.Request.URL.Host = "https", "foo.com"
+	exporter.Add(intercepted)
+	assert.Equal(t, "foo.com:443", exporter.TestSuite.API)
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/linuxsuren/api-testing/pkg/version"
+)
+
+// HAR is the HTTP Archive 1.2, see also http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []HARNameValue `json:"params,omitempty"`
+	Text     string         `json:"text"`
+	// Encoding is base64 if the text is not UTF-8, it is a custom field like the one of the content
+	Encoding string `json:"_encoding,omitempty"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+	// Events are the parsed events of the text/event-stream, it is a custom field
+	Events []ServerSentEvent `json:"_events,omitempty"`
+}
+
+// harTruncated is the comment of the truncated content
+const harTruncated = "truncated"
+
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARExporter exports the collected requests as a HAR file
+type HARExporter struct {
+	har HAR
+}
+
+// NewHARExporter creates a new HAR exporter
+func NewHARExporter() *HARExporter {
+	return &HARExporter{
+		har: HAR{Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "atest-collector", Version: version.GetVersion()},
+			Entries: []HAREntry{},
+		}},
+	}
+}
+
+// Add adds a request to the exporter
+func (e *HARExporter) Add(reqAndResp *RequestAndResponse) {
+	e.har.Log.Entries = append(e.har.Log.Entries, NewHAREntry(reqAndResp))
+}
+
+// Export exports the HAR file
+func (e *HARExporter) Export() (string, error) {
+	data, err := json.MarshalIndent(e.har, "", "  ")
+	return string(data), err
+}
+
+// NewHAREntry converts the collected request to a HAR entry
+func NewHAREntry(reqAndResp *RequestAndResponse) (entry HAREntry) {
+	r, resp := reqAndResp.Request, reqAndResp.Response
+	body := reqAndResp.ReadRequestBody()
+
+	entry.Request = HARRequest{
+		Method:      r.Method,
+		URL:         r.URL.String(),
+		HTTPVersion: getHTTPVersion(r.Proto),
+		Cookies:     []HARCookie{},
+		Headers:     toHARNameValues(r.Header),
+		QueryString: toHARNameValues(r.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for _, cookie := range r.Cookies() {
+		entry.Request.Cookies = append(entry.Request.Cookies, HARCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	if len(body) > 0 {
+		contentType := r.Header.Get("Content-Type")
+		entry.Request.PostData = &HARPostData{
+			MimeType: contentType,
+			Text:     string(body),
+		}
+		if !utf8.Valid(body) {
+			entry.Request.PostData.Text = base64.StdEncoding.EncodeToString(body)
+			entry.Request.PostData.Encoding = "base64"
+		}
+		if GetBodyKind(contentType) == BodyKindForm {
+			if form, err := ParseForm(contentType, body); err == nil {
+				entry.Request.PostData.Params = toHARNameValues(toValues(form))
+			}
+		}
+	}
+
+	entry.Response = HARResponse{
+		HTTPVersion: entry.Request.HTTPVersion,
+		Cookies:     []HARCookie{},
+		Headers:     []HARNameValue{},
+		HeadersSize: -1,
+	}
+	entry.Timings = HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	if resp != nil {
+		entry.StartedDateTime = resp.StartedAt
+		entry.Time = toMilliseconds(resp.Duration)
+		entry.Timings.Wait = entry.Time
+
+		entry.Response.Status = resp.StatusCode
+		entry.Response.StatusText = http.StatusText(resp.StatusCode)
+		entry.Response.Headers = toHARNameValues(resp.Header)
+		entry.Response.RedirectURL = resp.Header.Get("Location")
+		entry.Response.BodySize = len(resp.Body)
+		for _, cookie := range (&http.Response{Header: resp.Header}).Cookies() {
+			entry.Response.Cookies = append(entry.Response.Cookies, HARCookie{
+				Name:     cookie.Name,
+				Value:    cookie.Value,
+				Path:     cookie.Path,
+				Domain:   cookie.Domain,
+				HTTPOnly: cookie.HttpOnly,
+				Secure:   cookie.Secure,
+			})
+		}
+
+		contentType := resp.Header.Get("Content-Type")
+		entry.Response.Content = HARContent{
+			Size:     len(resp.Body),
+			MimeType: contentType,
+			Text:     resp.Body,
+			Events:   resp.Events(),
+		}
+		if resp.Truncated {
+			entry.Response.Content.Comment = harTruncated
+		}
+		if !utf8.ValidString(resp.Body) {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString([]byte(resp.Body))
+			entry.Response.Content.Encoding = "base64"
+		}
+	}
+	if entry.StartedDateTime.IsZero() {
+		entry.StartedDateTime = time.Now()
+	}
+	return
+}
+
+// ParseHARFile reads the HAR from a file
+func ParseHARFile(file string) (har *HAR, err error) {
+	var data []byte
+	if data, err = os.ReadFile(file); err == nil {
+		har, err = ParseHAR(data)
+	}
+	return
+}
+
+// ParseHAR parses the HAR, it could be exported from the browser devtools
+func ParseHAR(data []byte) (har *HAR, err error) {
+	har = &HAR{}
+	err = json.Unmarshal(data, har)
+	return
+}
+
+// ToRequestAndResponse converts the HAR entry to a collected request
+func (e HAREntry) ToRequestAndResponse() (reqAndResp *RequestAndResponse, err error) {
+	var body io.Reader
+	if postData := e.Request.PostData; postData != nil {
+		text := postData.Text
+		if text == "" && len(postData.Params) > 0 {
+			values := url.Values{}
+			for _, param := range postData.Params {
+				values.Add(param.Name, param.Value)
+			}
+			text = values.Encode()
+		} else if postData.Encoding == "base64" {
+			var data []byte
+			if data, err = base64.StdEncoding.DecodeString(text); err != nil {
+				return
+			}
+			text = string(data)
+		}
+		body = strings.NewReader(text)
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequest(e.Request.Method, e.Request.URL, body); err != nil {
+		return
+	}
+	if e.Request.HTTPVersion != "" {
+		req.Proto = strings.ToUpper(e.Request.HTTPVersion)
+	}
+	for _, header := range e.Request.Headers {
+		// skip the HTTP/2 pseudo headers, like :authority
+		if !strings.HasPrefix(header.Name, ":") {
+			req.Header.Add(header.Name, header.Value)
+		}
+	}
+	if e.Request.PostData != nil && req.Header.Get("Content-Type") == "" && e.Request.PostData.MimeType != "" {
+		req.Header.Set("Content-Type", e.Request.PostData.MimeType)
+	}
+
+	resp := &SimpleResponse{
+		StatusCode: e.Response.Status,
+		Header:     http.Header{},
+		Body:       e.Response.Content.Text,
+		StartedAt:  e.StartedDateTime,
+		Duration:   time.Duration(e.Time * float64(time.Millisecond)),
+		Truncated:  e.Response.Content.Comment == harTruncated,
+	}
+	for _, header := range e.Response.Headers {
+		if !strings.HasPrefix(header.Name, ":") {
+			resp.Header.Add(header.Name, header.Value)
+		}
+	}
+	if resp.Header.Get("Content-Type") == "" && e.Response.Content.MimeType != "" {
+		resp.Header.Set("Content-Type", e.Response.Content.MimeType)
+	}
+	if e.Response.Content.Encoding == "base64" {
+		var data []byte
+		if data, err = base64.StdEncoding.DecodeString(e.Response.Content.Text); err != nil {
+			return
+		}
+		resp.Body = string(data)
+	}
+
+	reqAndResp = &RequestAndResponse{Request: req, Response: resp}
+	return
+}
+
+func toHARNameValues(values map[string][]string) (pairs []HARNameValue) {
+	pairs = []HARNameValue{}
+	for name, items := range values {
+		for _, item := range items {
+			pairs = append(pairs, HARNameValue{Name: name, Value: item})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return
+}
+
+func toValues(fields map[string]string) map[string][]string {
+	values := make(map[string][]string, len(fields))
+	for key, value := range fields {
+		values[key] = []string{value}
+	}
+	return values
+}
+
+func getHTTPVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
+
+func toMilliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHARExporter(t *testing.T) {
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	request, err := http.NewRequest(http.MethodPost, "http://foo/api/v1/users?name=rick&age=18",
+		bytes.NewBufferString("name=rick"))
+	assert.NoError(t, err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Cookie", "session=abc")
+
+	exporter := pkg.NewHARExporter()
+	exporter.Add(&pkg.RequestAndResponse{
+		Request: request,
+		Response: &pkg.SimpleResponse{
+			StatusCode: http.StatusCreated,
+			Header: http.Header{
+				"Content-Type": []string{"application/json"},
+				"Set-Cookie":   []string{"token=def; Path=/; HttpOnly"},
+			},
+			Body:      `{"name":"rick"}`,
+			StartedAt: startedAt,
+			Duration:  1500 * time.Microsecond,
+		},
+	})
+	exporter.Add(&pkg.RequestAndResponse{
+		Request: request,
+		Response: &pkg.SimpleResponse{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/octet-stream"}},
+			Body:       string([]byte{0xff, 0xfe}),
+		},
+	})
+
+	data, err := exporter.Export()
+	assert.NoError(t, err)
+
+	har := &pkg.HAR{}
+	assert.NoError(t, json.Unmarshal([]byte(data), har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Equal(t, "atest-collector", har.Log.Creator.Name)
+	assert.Len(t, har.Log.Entries, 2)
+
+	entry := har.Log.Entries[0]
+	assert.True(t, startedAt.Equal(entry.StartedDateTime))
+	assert.Equal(t, 1.5, entry.Time)
+	assert.Equal(t, 1.5, entry.Timings.Wait)
+	assert.Equal(t, float64(-1), entry.Timings.DNS)
+	assert.Equal(t, http.MethodPost, entry.Request.Method)
+	assert.Equal(t, "http://foo/api/v1/users?name=rick&age=18", entry.Request.URL)
+	assert.Equal(t, []pkg.HARNameValue{{Name: "age", Value: "18"}, {Name: "name", Value: "rick"}},
+		entry.Request.QueryString)
+	assert.Equal(t, []pkg.HARCookie{{Name: "session", Value: "abc"}}, entry.Request.Cookies)
+	assert.Equal(t, "name=rick", entry.Request.PostData.Text)
+	assert.Equal(t, []pkg.HARNameValue{{Name: "name", Value: "rick"}}, entry.Request.PostData.Params)
+	assert.Equal(t, 9, entry.Request.BodySize)
+	assert.Equal(t, http.StatusCreated, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, `{"name":"rick"}`, entry.Response.Content.Text)
+	assert.Equal(t, "application/json", entry.Response.Content.MimeType)
+	assert.Equal(t, []pkg.HARCookie{{Name: "token", Value: "def", Path: "/", HTTPOnly: true}}, entry.Response.Cookies)
+
+	entry = har.Log.Entries[1]
+	assert.Equal(t, "name=rick", entry.Request.PostData.Text)
+	assert.Equal(t, "base64", entry.Response.Content.Encoding)
+	assert.Equal(t, "//4=", entry.Response.Content.Text)
+}
+
+func TestParseHAR(t *testing.T) {
+	har, err := pkg.ParseHARFile("testdata/sample.har")
+	assert.NoError(t, err)
+	assert.Len(t, har.Log.Entries, 3)
+
+	reqAndResp, err := har.Log.Entries[0].ToRequestAndResponse()
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, reqAndResp.Request.Method)
+	assert.Equal(t, "https://foo.com/api/v1/users?debug=true", reqAndResp.Request.URL.String())
+	assert.Equal(t, "HTTP/2.0", reqAndResp.Request.Proto)
+	assert.Equal(t, "Bearer token", reqAndResp.Request.Header.Get("Authorization"))
+	assert.Empty(t, reqAndResp.Request.Header.Get(":authority"))
+	assert.Equal(t, `{"name":"rick"}`, string(reqAndResp.ReadRequestBody()))
+	assert.Equal(t, http.StatusCreated, reqAndResp.Response.StatusCode)
+	assert.Equal(t, `{"id":1}`, reqAndResp.Response.Body)
+	assert.Equal(t, 12500*time.Microsecond, reqAndResp.Response.Duration)
+
+	reqAndResp, err = har.Log.Entries[1].ToRequestAndResponse()
+	assert.NoError(t, err)
+	assert.Equal(t, "user=rick", string(reqAndResp.ReadRequestBody()))
+	assert.Equal(t, "application/x-www-form-urlencoded", reqAndResp.Request.Header.Get("Content-Type"))
+	assert.Equal(t, "application/json; charset=utf-8", reqAndResp.Response.Header.Get("Content-Type"))
+
+	// export then parse again
+	exporter := pkg.NewHARExporter()
+	exporter.Add(reqAndResp)
+	data, err := exporter.Export()
+	assert.NoError(t, err)
+	har, err = pkg.ParseHAR([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://foo.com/api/v1/login", har.Log.Entries[0].Request.URL)
+
+	_, err = pkg.ParseHARFile("testdata/fake.har")
+	assert.Error(t, err)
+	_, err = pkg.HAREntry{Request: pkg.HARRequest{Method: " ", URL: "http://foo"}}.ToRequestAndResponse()
+	assert.Error(t, err)
+}
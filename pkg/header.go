@@ -0,0 +1,140 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SecretMode is the way to keep the secret headers
+type SecretMode string
+
+const (
+	// SecretModeTemplate replaces the secret with an api-testing template which reads an environment variable
+	SecretModeTemplate SecretMode = "template"
+	// SecretModeMask replaces the secret with a fixed mask
+	SecretModeMask SecretMode = "mask"
+	// SecretModeKeep keeps the secret as it is
+	SecretModeKeep SecretMode = "keep"
+)
+
+const secretMask = "******"
+
+// DefaultDropHeaders are the hop-by-hop and noisy headers
+var DefaultDropHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+	"Content-Length", "Content-Encoding", "Host", "Accept-Encoding", "If-Modified-Since", "If-None-Match",
+	"Cache-Control", "Pragma", "Priority", "Dnt", "Upgrade-Insecure-Requests", "Sec-*",
+}
+
+// DefaultMaskHeaders are the headers which usually carry secrets
+var DefaultMaskHeaders = []string{
+	"Authorization", "Cookie", "X-Api-Key", "Api-Key", "X-Auth-Token", "X-Csrf-Token", "X-Xsrf-Token",
+}
+
+// HeaderPolicy decides how to keep the request headers, the patterns are case-insensitive globs
+type HeaderPolicy struct {
+	Drop []string `yaml:"drop"`
+	// Keep are the headers which are recorded even if they are dropped
+	Keep []string   `yaml:"keep"`
+	Mask []string   `yaml:"mask"`
+	Mode SecretMode `yaml:"mode"`
+}
+
+// NewHeaderPolicy creates the default header policy
+func NewHeaderPolicy() *HeaderPolicy {
+	return &HeaderPolicy{
+		Drop: append([]string{}, DefaultDropHeaders...),
+		Mask: append([]string{}, DefaultMaskHeaders...),
+		Mode: SecretModeTemplate,
+	}
+}
+
+// Validate checks the patterns and the secret mode
+func (p *HeaderPolicy) Validate() (err error) {
+	for _, patterns := range [][]string{p.Drop, p.Keep, p.Mask} {
+		for _, pattern := range patterns {
+			if _, err = path.Match(pattern, ""); err != nil {
+				err = fmt.Errorf("invalid header pattern %q: %v", pattern, err)
+				return
+			}
+		}
+	}
+
+	switch p.Mode {
+	case "", SecretModeTemplate, SecretModeMask, SecretModeKeep:
+	default:
+		err = fmt.Errorf("not support secret mode: %q", p.Mode)
+	}
+	return
+}
+
+// Apply returns the headers which should be kept in the test case
+func (p *HeaderPolicy) Apply(header http.Header) (result map[string]string) {
+	result = make(map[string]string, len(header))
+	for key, values := range header {
+		name := http.CanonicalHeaderKey(key)
+		if len(values) == 0 || (matchHeader(p.Drop, name) && !matchHeader(p.Keep, name)) {
+			continue
+		}
+
+		value := strings.Join(values, ", ")
+		if name == "Cookie" {
+			value = strings.Join(values, "; ")
+		}
+		if matchHeader(p.Mask, name) {
+			value = p.secret(name, value)
+		}
+		result[name] = value
+	}
+	return
+}
+
+// IsSecret returns true if the header should be masked
+func (p *HeaderPolicy) IsSecret(name string) bool {
+	return matchHeader(p.Mask, http.CanonicalHeaderKey(name))
+}
+
+func (p *HeaderPolicy) secret(name, value string) string {
+	switch p.Mode {
+	case SecretModeMask:
+		return secretMask
+	case SecretModeKeep:
+		return value
+	default:
+		return fmt.Sprintf(`{{env "%s"}}`, GetSecretEnvName(name))
+	}
+}
+
+// GetSecretEnvName returns the environment variable name of a secret header
+func GetSecretEnvName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func matchHeader(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderPolicy(t *testing.T) {
+	header := http.Header{
+		"Accept":           []string{"application/json"},
+		"X-Tenant-Id":      []string{"tenant"},
+		"X-Request-Id":     []string{"1", "2"},
+		"Authorization":    []string{"Bearer token"},
+		"Cookie":           []string{"a=b", "c=d"},
+		"X-Api-Key":        []string{"key"},
+		"Connection":       []string{"keep-alive"},
+		"Accept-Encoding":  []string{"gzip"},
+		"Sec-Fetch-Mode":   []string{"cors"},
+		"Proxy-Connection": []string{"keep-alive"},
+		"Empty":            []string{},
+	}
+
+	policy := pkg.NewHeaderPolicy()
+	assert.NoError(t, policy.Validate())
+	assert.Equal(t, map[string]string{
+		"Accept":        "application/json",
+		"X-Tenant-Id":   "tenant",
+		"X-Request-Id":  "1, 2",
+		"Authorization": `{{env "AUTHORIZATION"}}`,
+		"Cookie":        `{{env "COOKIE"}}`,
+		"X-Api-Key":     `{{env "X_API_KEY"}}`,
+	}, policy.Apply(header))
+	assert.True(t, policy.IsSecret("x-api-key"))
+	assert.False(t, policy.IsSecret("accept"))
+
+	policy.Mode = pkg.SecretModeMask
+	policy.Drop = append(policy.Drop, "x-request-*")
+	result := policy.Apply(header)
+	assert.Equal(t, "******", result["Authorization"])
+	assert.NotContains(t, result, "X-Request-Id")
+
+	policy.Mode = pkg.SecretModeKeep
+	result = policy.Apply(header)
+	assert.Equal(t, "Bearer token", result["Authorization"])
+	assert.Equal(t, "a=b; c=d", result["Cookie"])
+
+	policy.Keep = []string{"connection"}
+	result = policy.Apply(header)
+	assert.Equal(t, "keep-alive", result["Connection"])
+	assert.NotContains(t, result, "Proxy-Connection")
+
+	assert.Error(t, (&pkg.HeaderPolicy{Drop: []string{"["}}).Validate())
+	assert.Error(t, (&pkg.HeaderPolicy{Keep: []string{"["}}).Validate())
+	assert.Error(t, (&pkg.HeaderPolicy{Mode: "fake"}).Validate())
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Journal is an append-only file which keeps every collected request as a HAR entry per line
+type Journal struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// OpenJournal opens the journal file, the existing entries are kept if resume is true.
+// Only the owner could read it because the raw headers are kept, like the tokens and cookies.
+func OpenJournal(path string, resume bool) (journal *Journal, err error) {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		flag |= os.O_TRUNC
+	}
+
+	var file *os.File
+	if file, err = os.OpenFile(path, flag, 0600); err != nil {
+		return
+	}
+	// the permission of an existing file is not changed by opening it
+	if err = file.Chmod(0600); err != nil {
+		_ = file.Close()
+		return
+	}
+	journal = &Journal{file: file}
+	return
+}
+
+// Append writes a request into the journal
+func (j *Journal) Append(reqAndResp *RequestAndResponse) (err error) {
+	var data []byte
+	if data, err = json.Marshal(NewHAREntry(reqAndResp)); err != nil {
+		return
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	_, err = j.file.Write(append(data, '\n'))
+	return
+}
+
+// Add is the EventHandle of the journal
+func (j *Journal) Add(reqAndResp *RequestAndResponse) {
+	if err := j.Append(reqAndResp); err != nil {
+		log.Println("failed to write the journal", err)
+	}
+}
+
+// Close closes the journal file
+func (j *Journal) Close() (err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if err = j.file.Sync(); err == nil {
+		err = j.file.Close()
+	}
+	return
+}
+
+// ReadJournal reads all the requests from a journal file.
+// The last line is ignored if it is incomplete, it usually happens when the process was killed.
+func ReadJournal(path string) (items []*RequestAndResponse, err error) {
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			entry := HAREntry{}
+			if err = json.Unmarshal(line, &entry); err != nil {
+				return
+			}
+
+			var item *RequestAndResponse
+			if item, err = entry.ToRequestAndResponse(); err != nil {
+				return
+			}
+			items = append(items, item)
+		}
+
+		if readErr != nil {
+			return
+		}
+	}
+}
+
+// WriteFileAtomic writes data into a temporary file, then renames it to the target file.
+// The target file is either the old one or the new one, it will never be a partial one.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	var tmp *os.File
+	if tmp, err = os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp"); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if err = os.Chmod(tmp.Name(), perm); err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+	}
+	return
+}
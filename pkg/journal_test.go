@@ -0,0 +1,111 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+// binaryBody is not UTF-8, it is corrupted if it is kept as a JSON string
+var binaryBody = []byte{0x89, 'P', 'N', 'G', 0xff, 0xfe, 0x00}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample.journal")
+
+	items, err := pkg.ReadJournal(path)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	assert.NoError(t, os.WriteFile(path, nil, 0644))
+	journal, err := pkg.OpenJournal(path, false)
+	assert.NoError(t, err)
+	// the raw headers could be read by the owner only
+	if info, err := os.Stat(path); assert.NoError(t, err) && runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+	for _, api := range []string{"http://foo/api/v1", "http://foo/api/v2", "http://foo/api/v3"} {
+		body := []byte("hello")
+		if strings.HasSuffix(api, "v3") {
+			body = binaryBody
+		}
+		request, _ := http.NewRequest(http.MethodPost, api, bytes.NewBuffer(body))
+		journal.Add(&pkg.RequestAndResponse{
+			Request:  request,
+			Response: &pkg.SimpleResponse{StatusCode: http.StatusOK, Body: "world"},
+		})
+	}
+	assert.NoError(t, journal.Close())
+
+	// simulate an incomplete line which is written when the process was killed
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, _ = file.WriteString(`{"request":{"method":"GET"`)
+	assert.NoError(t, file.Close())
+
+	items, err = pkg.ReadJournal(path)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+	assert.Equal(t, "http://foo/api/v2", items[1].Request.URL.String())
+	assert.Equal(t, "hello", string(items[1].ReadRequestBody()))
+	assert.Equal(t, "world", items[1].Response.Body)
+	// the binary bodies are kept as they are
+	assert.Equal(t, binaryBody, items[2].ReadRequestBody())
+
+	// resume keeps the existing entries
+	journal, err = pkg.OpenJournal(path, true)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.Close())
+	items, err = pkg.ReadJournal(path)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	journal, err = pkg.OpenJournal(path, false)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.Close())
+	items, err = pkg.ReadJournal(path)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	assert.NoError(t, os.WriteFile(path, []byte("fake\n"), 0644))
+	_, err = pkg.ReadJournal(path)
+	assert.Error(t, err)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sample.yaml")
+	assert.NoError(t, pkg.WriteFileAtomic(path, []byte("hello"), 0644))
+	assert.NoError(t, pkg.WriteFileAtomic(path, []byte("world"), 0644))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Error(t, pkg.WriteFileAtomic(filepath.Join(dir, "fake", "sample.yaml"), nil, 0644))
+}
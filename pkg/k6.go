@@ -0,0 +1,231 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minThinkTime is the min gap between two requests which is kept as a sleep
+const minThinkTime = 10 * time.Millisecond
+
+// K6Exporter generates a k6 load-test script from the traffic. It should be added as a raw event handle,
+// then the requests are kept in the same order and frequency as the recording.
+type K6Exporter struct {
+	lock         sync.Mutex
+	headerPolicy *HeaderPolicy
+	weighted     bool
+	requests     []*k6Request
+}
+
+type k6Request struct {
+	key     string
+	name    string
+	method  string
+	url     string
+	headers map[string]string
+	secrets map[string]string
+	body    string
+	// binary means the body is base64 encoded, it is decoded by k6
+	binary      bool
+	compression string
+	status      int
+	startedAt   time.Time
+	duration    time.Duration
+}
+
+// NewK6Exporter creates a k6 exporter
+func NewK6Exporter() *K6Exporter {
+	return &K6Exporter{headerPolicy: NewHeaderPolicy()}
+}
+
+// WithHeaderPolicy sets the policy of the request headers, the secret headers
+// are read from the environment variables in the template mode
+func (e *K6Exporter) WithHeaderPolicy(policy *HeaderPolicy) *K6Exporter {
+	e.headerPolicy = policy
+	return e
+}
+
+// WithWeighted picks the requests randomly by the observed frequency in each iteration,
+// instead of replaying the whole recording with the think times
+func (e *K6Exporter) WithWeighted(weighted bool) *K6Exporter {
+	e.weighted = weighted
+	return e
+}
+
+// Add adds a request to the script
+func (e *K6Exporter) Add(reqAndResp *RequestAndResponse) {
+	r, resp := reqAndResp.Request, reqAndResp.Response
+	template, _ := NormalizePath(r.URL.Path)
+	request := &k6Request{
+		key:     reqAndResp.Key,
+		name:    fmt.Sprintf("%s %s", r.Method, template),
+		method:  r.Method,
+		url:     r.URL.String(),
+		headers: e.headerPolicy.Apply(r.Header),
+		secrets: map[string]string{},
+	}
+	if request.key == "" {
+		request.key = (&URLKeyStrategy{}).Key(reqAndResp)
+	}
+	for name := range request.headers {
+		if e.headerPolicy.IsSecret(name) && e.headerPolicy.Mode == SecretModeTemplate {
+			request.secrets[name] = GetSecretEnvName(name)
+		}
+	}
+	if data := reqAndResp.ReadRequestBody(); len(data) > 0 {
+		request.body = string(data)
+		// the body is recorded decoded, k6 compresses it again
+		request.compression = strings.Join(ParseContentEncoding(r.Header.Get("Content-Encoding")), ", ")
+		if contentType := r.Header.Get("Content-Type"); contentType != "" && GetBodyKind(contentType) == BodyKindBinary {
+			request.body, request.binary = base64.StdEncoding.EncodeToString(data), true
+		}
+	}
+	if resp != nil {
+		request.status = resp.StatusCode
+		request.startedAt = resp.StartedAt
+		request.duration = resp.Duration
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.requests = append(e.requests, request)
+}
+
+// Export exports the k6 script
+func (e *K6Exporter) Export() (string, error) {
+	e.lock.Lock()
+	requests := make([]*k6Request, len(e.requests))
+	copy(requests, e.requests)
+	e.lock.Unlock()
+
+	// the responses may arrive in a different order, the requests are sorted by the time they were sent
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].startedAt.Before(requests[j].startedAt)
+	})
+
+	buf := new(strings.Builder)
+	buf.WriteString("// generated by atest-collector, run it with: k6 run script.js\n")
+	buf.WriteString("import http from 'k6/http';\nimport { check, sleep } from 'k6';\n")
+	for _, request := range requests {
+		if request.binary {
+			buf.WriteString("import encoding from 'k6/encoding';\n")
+			break
+		}
+	}
+	buf.WriteString("\n")
+	if e.weighted {
+		e.writeWeighted(buf, requests)
+	} else {
+		e.writeSequence(buf, requests)
+	}
+	return buf.String(), nil
+}
+
+func (e *K6Exporter) writeSequence(buf *strings.Builder, requests []*k6Request) {
+	buf.WriteString("export const options = {\n  vus: 1,\n  iterations: 1,\n};\n\n")
+	buf.WriteString("export default function () {\n  let res;\n")
+	for i, request := range requests {
+		if i > 0 {
+			previous := requests[i-1]
+			if thinkTime := request.startedAt.Sub(previous.startedAt.Add(previous.duration)); thinkTime >= minThinkTime {
+				fmt.Fprintf(buf, "  sleep(%.2f);\n", thinkTime.Seconds())
+			}
+		}
+		buf.WriteString("\n")
+		writeK6Request(buf, request, "  ")
+	}
+	buf.WriteString("}\n")
+}
+
+func (e *K6Exporter) writeWeighted(buf *strings.Builder, requests []*k6Request) {
+	var keys []string
+	weights := map[string]int{}
+	samples := map[string]*k6Request{}
+	for _, request := range requests {
+		if _, ok := samples[request.key]; !ok {
+			keys = append(keys, request.key)
+			samples[request.key] = request
+		}
+		weights[request.key]++
+	}
+
+	buf.WriteString("export const options = {\n  vus: 10,\n  duration: '1m',\n};\n\n")
+	buf.WriteString("// the weights are the observed frequencies of the requests\nconst requests = [\n")
+	for _, key := range keys {
+		fmt.Fprintf(buf, "  { weight: %d, exec: () => {\n    let res;\n", weights[key])
+		writeK6Request(buf, samples[key], "    ")
+		buf.WriteString("  } },\n")
+	}
+	buf.WriteString("];\n\n")
+	buf.WriteString(`const total = requests.reduce((sum, r) => sum + r.weight, 0);
+
+export default function () {
+  let pick = Math.random() * total;
+  for (const r of requests) {
+    pick -= r.weight;
+    if (pick < 0) {
+      r.exec();
+      break;
+    }
+  }
+}
+`)
+}
+
+func writeK6Request(buf *strings.Builder, request *k6Request, indent string) {
+	body := "null"
+	if request.binary {
+		// the binary bodies are sent as ArrayBuffer
+		body = fmt.Sprintf("encoding.b64decode(%s)", ToJSString(request.body))
+	} else if request.body != "" {
+		body = ToJSString(request.body)
+	}
+
+	var headers []string
+	for _, name := range SortedKeys(request.headers) {
+		value := ToJSString(request.headers[name])
+		if env, ok := request.secrets[name]; ok {
+			value = "__ENV." + env
+		}
+		headers = append(headers, fmt.Sprintf("%s: %s", ToJSString(name), value))
+	}
+
+	fmt.Fprintf(buf, "%sres = http.request(%s, %s, %s, {\n", indent, ToJSString(request.method), ToJSString(request.url), body)
+	fmt.Fprintf(buf, "%s  headers: { %s },\n", indent, strings.Join(headers, ", "))
+	if request.compression != "" {
+		fmt.Fprintf(buf, "%s  compression: %s,\n", indent, ToJSString(request.compression))
+	}
+	fmt.Fprintf(buf, "%s  tags: { name: %s },\n", indent, ToJSString(request.name))
+	fmt.Fprintf(buf, "%s});\n", indent)
+	if request.status > 0 {
+		fmt.Fprintf(buf, "%scheck(res, { %s: (r) => r.status === %d });\n", indent,
+			ToJSString(fmt.Sprintf("%s is %d", request.name, request.status)), request.status)
+	}
+}
+
+// ToJSString returns the JavaScript string literal, JSON strings are valid in JavaScript
+func ToJSString(text string) string {
+	data, _ := json.Marshal(text)
+	return string(data)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	_ "embed"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestK6Exporter(t *testing.T) {
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	newReqAndResp := func(method, api, body string, offset time.Duration, code int) *pkg.RequestAndResponse {
+		request, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+		request.Header.Set("Authorization", "Bearer token")
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		return &pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+			StatusCode: code,
+			StartedAt:  startedAt.Add(offset),
+			Duration:   100 * time.Millisecond,
+		}}
+	}
+
+	exporter := pkg.NewK6Exporter()
+	weighted := pkg.NewK6Exporter().WithWeighted(true)
+	for _, reqAndResp := range []*pkg.RequestAndResponse{
+		// the response of the login arrives later than the first request
+		newReqAndResp(http.MethodGet, "http://foo/api/users/1", "", 2*time.Second, http.StatusOK),
+		newReqAndResp(http.MethodPost, "http://foo/api/login", `{"user":"rick"}`, 0, http.StatusOK),
+		newReqAndResp(http.MethodGet, "http://foo/api/users/2", "", 2105*time.Millisecond, http.StatusNotFound),
+	} {
+		exporter.Add(reqAndResp)
+		weighted.Add(reqAndResp)
+	}
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+	assert.Equal(t, k6Script, result, result)
+
+	result, err = weighted.Export()
+	assert.NoError(t, err)
+	assert.Contains(t, result, "{ weight: 1, exec: () => {")
+	assert.Contains(t, result, `check(res, { "GET /api/users/{userId} is 404": (r) => r.status === 404 });`)
+
+	// the decoded body is compressed again by k6
+	compressed := newReqAndResp(http.MethodPost, "http://foo/api/upload", `{"user":"rick"}`, 0, http.StatusOK)
+	compressed.Request.Header.Set("Content-Encoding", "x-gzip")
+	exporter = pkg.NewK6Exporter()
+	exporter.Add(compressed)
+	result, err = exporter.Export()
+	assert.NoError(t, err)
+	assert.Contains(t, result, `compression: "gzip",`)
+	assert.NotContains(t, result, `"Content-Encoding"`)
+
+	// the binary body is sent as it is
+	binary := newReqAndResp(http.MethodPost, "http://foo/api/upload", "\x89PNG\x00", 0, http.StatusOK)
+	binary.Request.Header.Set("Content-Type", "image/png")
+	exporter = pkg.NewK6Exporter()
+	exporter.Add(binary)
+	result, err = exporter.Export()
+	assert.NoError(t, err)
+	assert.Contains(t, result, "import encoding from 'k6/encoding';")
+	assert.Contains(t, result, `"POST", "http://foo/api/upload", encoding.b64decode("iVBORwA="), {`)
+	assert.NotContains(t, result, "{{")
+}
+
+//go:embed testdata/k6_script.js
+var k6Script string
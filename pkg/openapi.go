@@ -0,0 +1,294 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIVersion is the version of the generated OpenAPI documents,
+// the schemas of 3.1 are the same as the JSON Schema
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPI is the subset of the OpenAPI 3 document which could be inferred from the traffic
+type OpenAPI struct {
+	OpenAPI string                                  `yaml:"openapi"`
+	Info    OpenAPIInfo                             `yaml:"info"`
+	Servers []OpenAPIServer                         `yaml:"servers,omitempty"`
+	Paths   map[string]map[string]*OpenAPIOperation `yaml:"paths"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+type OpenAPIServer struct {
+	URL string `yaml:"url"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `yaml:"operationId"`
+	Parameters  []*OpenAPIParameter         `yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `yaml:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `yaml:"responses"`
+
+	// observed is the count of the requests, the parameters of all requests are required
+	observed int
+}
+
+type OpenAPIParameter struct {
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+	Example  string  `yaml:"example,omitempty"`
+
+	observed int
+}
+
+type OpenAPIRequestBody struct {
+	Content map[string]*OpenAPIMediaType `yaml:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `yaml:"description"`
+	Content     map[string]*OpenAPIMediaType `yaml:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// OpenAPIExporter generates an OpenAPI document from the traffic. It should be added as
+// a raw event handle, then the duplicated requests, like different status codes, are merged.
+type OpenAPIExporter struct {
+	lock    sync.Mutex
+	title   string
+	servers map[string]bool
+	paths   map[string]map[string]*OpenAPIOperation
+}
+
+// NewOpenAPIExporter creates an OpenAPI exporter
+func NewOpenAPIExporter(title string) *OpenAPIExporter {
+	return &OpenAPIExporter{
+		title:   title,
+		servers: map[string]bool{},
+		paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+}
+
+// Add merges a request into the document
+func (e *OpenAPIExporter) Add(reqAndResp *RequestAndResponse) {
+	r, resp := reqAndResp.Request, reqAndResp.Response
+	body := reqAndResp.ReadRequestBody()
+	template, params := NormalizePath(r.URL.Path)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if r.URL.Host != "" {
+		e.servers[fmt.Sprintf("%s://%s", r.URL.Scheme, r.URL.Host)] = true
+	}
+
+	operations, ok := e.paths[template]
+	if !ok {
+		operations = map[string]*OpenAPIOperation{}
+		e.paths[template] = operations
+	}
+	method := strings.ToLower(r.Method)
+	operation, ok := operations[method]
+	if !ok {
+		operation = &OpenAPIOperation{Responses: map[string]*OpenAPIResponse{}}
+		operations[method] = operation
+	}
+	operation.observed++
+
+	for _, param := range params {
+		operation.addParameter(param.Name, "path", param.Value)
+	}
+	for name, values := range r.URL.Query() {
+		operation.addParameter(name, "query", values[0])
+	}
+
+	if len(body) > 0 {
+		if operation.RequestBody == nil {
+			operation.RequestBody = &OpenAPIRequestBody{Content: map[string]*OpenAPIMediaType{}}
+		}
+		addMediaType(operation.RequestBody.Content, r.Header.Get("Content-Type"), body)
+	}
+
+	if resp != nil {
+		code := strconv.Itoa(resp.StatusCode)
+		response, ok := operation.Responses[code]
+		if !ok {
+			response = &OpenAPIResponse{Description: http.StatusText(resp.StatusCode)}
+			operation.Responses[code] = response
+		}
+		if resp.Body != "" {
+			if response.Content == nil {
+				response.Content = map[string]*OpenAPIMediaType{}
+			}
+			addMediaType(response.Content, resp.Header.Get("Content-Type"), []byte(resp.Body))
+		}
+	}
+}
+
+// Export exports the OpenAPI document as YAML
+func (e *OpenAPIExporter) Export() (string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	doc := OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info:    OpenAPIInfo{Title: e.title, Version: "0.0.1"},
+		Paths:   e.paths,
+	}
+	for server := range e.servers {
+		doc.Servers = append(doc.Servers, OpenAPIServer{URL: server})
+	}
+	sort.Slice(doc.Servers, func(i, j int) bool {
+		return doc.Servers[i].URL < doc.Servers[j].URL
+	})
+
+	ids := map[string]int{}
+	for _, path := range SortedKeys(e.paths) {
+		for _, method := range SortedKeys(e.paths[path]) {
+			operation := e.paths[path][method]
+			operation.OperationID = uniqueOperationID(ids, operationID(method, path))
+			for _, param := range operation.Parameters {
+				param.Required = param.In == "path" || param.observed == operation.observed
+			}
+		}
+	}
+
+	data, err := yaml.Marshal(doc)
+	return string(data), err
+}
+
+func (o *OpenAPIOperation) addParameter(name, in, value string) {
+	for _, param := range o.Parameters {
+		if param.Name == name && param.In == in {
+			param.observed++
+			param.Schema = MergeSchema(param.Schema, inferParameterSchema(value))
+			return
+		}
+	}
+	o.Parameters = append(o.Parameters, &OpenAPIParameter{
+		Name:     name,
+		In:       in,
+		Schema:   inferParameterSchema(value),
+		Example:  value,
+		observed: 1,
+	})
+	sort.SliceStable(o.Parameters, func(i, j int) bool {
+		if o.Parameters[i].In != o.Parameters[j].In {
+			return o.Parameters[i].In == "path"
+		}
+		return o.Parameters[i].Name < o.Parameters[j].Name
+	})
+}
+
+// inferParameterSchema infers the schema of a path or query parameter
+func inferParameterSchema(value string) *Schema {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return &Schema{Type: SchemaTypes{"integer"}}
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return &Schema{Type: SchemaTypes{"number"}}
+	}
+	if value == "true" || value == "false" {
+		return &Schema{Type: SchemaTypes{"boolean"}}
+	}
+	return &Schema{Type: SchemaTypes{"string"}}
+}
+
+// addMediaType merges the schema of a body into the content
+func addMediaType(content map[string]*OpenAPIMediaType, contentType string, body []byte) {
+	mediaType := getMediaType(contentType)
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	var schema *Schema
+	switch GetBodyKind(mediaType) {
+	case BodyKindJSON:
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err == nil {
+			schema = InferSchema(data)
+		}
+	case BodyKindForm:
+		if form, err := ParseForm(contentType, body); err == nil {
+			fields := map[string]interface{}{}
+			for key, value := range form {
+				fields[key] = value
+			}
+			schema = InferSchema(fields)
+		}
+	case BodyKindBinary:
+		schema = &Schema{Type: SchemaTypes{"string"}, Format: "binary"}
+	}
+	if schema == nil {
+		schema = &Schema{Type: SchemaTypes{"string"}}
+	}
+
+	if existing, ok := content[mediaType]; ok {
+		existing.Schema = MergeSchema(existing.Schema, schema)
+	} else {
+		content[mediaType] = &OpenAPIMediaType{Schema: schema}
+	}
+}
+
+// operationID returns the ID like getUsersOrders of the path /users/{userId}/orders
+func operationID(method, template string) string {
+	id := method
+	for _, segment := range strings.Split(template, "/") {
+		if segment == "" || strings.HasPrefix(segment, "{") {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+func uniqueOperationID(ids map[string]int, id string) string {
+	if ids[id]++; ids[id] > 1 {
+		return fmt.Sprintf("%s%d", id, ids[id])
+	}
+	return id
+}
+
+// SortedKeys returns the sorted keys of a map
+func SortedKeys[T any](items map[string]T) (keys []string) {
+	keys = make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
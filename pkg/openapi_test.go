@@ -0,0 +1,74 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestOpenAPIExporter(t *testing.T) {
+	exporter := pkg.NewOpenAPIExporter("sample")
+	add := func(method, api, body string, code int, respBody string) {
+		request, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		exporter.Add(&pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+			StatusCode: code,
+			Header:     http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+			Body:       respBody,
+		}})
+	}
+	add(http.MethodGet, "http://foo/users/1?page=1", "", http.StatusOK, `{"id": 1, "name": "rick"}`)
+	add(http.MethodGet, "http://foo/users/2", "", http.StatusOK, `{"id": 2, "name": null}`)
+	add(http.MethodGet, "http://foo/users/3", "", http.StatusNotFound, `{"message": "not found"}`)
+	add(http.MethodPost, "https://bar/users", `{"name": "rick"}`, http.StatusCreated, "")
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+
+	doc := &pkg.OpenAPI{}
+	assert.NoError(t, yaml.Unmarshal([]byte(result), doc))
+	assert.Equal(t, pkg.OpenAPIVersion, doc.OpenAPI)
+	assert.Equal(t, []pkg.OpenAPIServer{{URL: "http://foo"}, {URL: "https://bar"}}, doc.Servers)
+
+	get := doc.Paths["/users/{userId}"]["get"]
+	assert.Equal(t, "getUsers", get.OperationID)
+	assert.Len(t, get.Parameters, 2)
+	assert.Equal(t, "userId", get.Parameters[0].Name)
+	assert.Equal(t, "path", get.Parameters[0].In)
+	assert.True(t, get.Parameters[0].Required)
+	assert.Equal(t, pkg.SchemaTypes{"integer"}, get.Parameters[0].Schema.Type)
+	assert.Equal(t, "page", get.Parameters[1].Name)
+	assert.False(t, get.Parameters[1].Required)
+	assert.Equal(t, "Not Found", get.Responses["404"].Description)
+
+	okSchema := get.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, pkg.SchemaTypes{"null", "string"}, okSchema.Properties["name"].Type)
+	assert.Equal(t, []string{"id", "name"}, okSchema.Required)
+
+	post := doc.Paths["/users"]["post"]
+	assert.Equal(t, "postUsers", post.OperationID)
+	assert.Equal(t, pkg.SchemaTypes{"object"}, post.RequestBody.Content["application/json"].Schema.Type)
+	assert.Nil(t, post.Responses["201"].Content)
+}
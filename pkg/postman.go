@@ -0,0 +1,314 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PostmanSchema is the schema of the Postman collection v2.1
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanCollection is the Postman collection v2.1, the folders are items which have sub items
+type PostmanCollection struct {
+	Info     PostmanInfo       `json:"info"`
+	Item     []*PostmanItem    `json:"item"`
+	Variable []PostmanKeyValue `json:"variable,omitempty"`
+}
+
+type PostmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type PostmanItem struct {
+	Name     string             `json:"name"`
+	Item     []*PostmanItem     `json:"item,omitempty"`
+	Request  *PostmanRequest    `json:"request,omitempty"`
+	Response []*PostmanResponse `json:"response,omitempty"`
+}
+
+type PostmanRequest struct {
+	Method string            `json:"method"`
+	Header []PostmanKeyValue `json:"header"`
+	Body   *PostmanBody      `json:"body,omitempty"`
+	URL    PostmanURL        `json:"url"`
+}
+
+type PostmanURL struct {
+	Raw   string            `json:"raw"`
+	Host  []string          `json:"host"`
+	Path  []string          `json:"path,omitempty"`
+	Query []PostmanKeyValue `json:"query,omitempty"`
+}
+
+type PostmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw,omitempty"`
+	URLEncoded []PostmanKeyValue `json:"urlencoded,omitempty"`
+	FormData   []PostmanKeyValue `json:"formdata,omitempty"`
+	File       *PostmanFile      `json:"file,omitempty"`
+	Options    *PostmanOptions   `json:"options,omitempty"`
+}
+
+// PostmanFile is the file of a binary body, it is selected in Postman before sending the request
+type PostmanFile struct {
+	Src string `json:"src"`
+}
+
+type PostmanOptions struct {
+	Raw PostmanRawOptions `json:"raw"`
+}
+
+type PostmanRawOptions struct {
+	Language string `json:"language"`
+}
+
+type PostmanResponse struct {
+	Name            string            `json:"name"`
+	OriginalRequest *PostmanRequest   `json:"originalRequest"`
+	Status          string            `json:"status"`
+	Code            int               `json:"code"`
+	PreviewLanguage string            `json:"_postman_previewlanguage,omitempty"`
+	Header          []PostmanKeyValue `json:"header"`
+	Body            string            `json:"body"`
+}
+
+type PostmanKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+var nonVariableChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// PostmanExporter exports the traffic as a Postman collection, the items are grouped by the
+// host and path prefix. It should be added as a raw event handle, then the responses of the
+// duplicated requests are saved as the examples, one example per status code.
+type PostmanExporter struct {
+	lock         sync.Mutex
+	name         string
+	headerPolicy *HeaderPolicy
+	hosts        map[string]*postmanHost
+	items        map[string]*PostmanItem
+	secrets      map[string]bool
+}
+
+type postmanHost struct {
+	baseURL  string
+	variable string
+	folders  map[string]*PostmanItem
+	items    []*PostmanItem
+}
+
+// NewPostmanExporter creates a Postman exporter
+func NewPostmanExporter(name string) *PostmanExporter {
+	return &PostmanExporter{
+		name:         name,
+		headerPolicy: NewHeaderPolicy(),
+		hosts:        map[string]*postmanHost{},
+		items:        map[string]*PostmanItem{},
+		secrets:      map[string]bool{},
+	}
+}
+
+// WithHeaderPolicy sets the policy of the request headers, the secret headers
+// become the collection variables in the template mode
+func (e *PostmanExporter) WithHeaderPolicy(policy *HeaderPolicy) *PostmanExporter {
+	e.headerPolicy = policy
+	return e
+}
+
+// Add adds a request or an example of the existing request
+func (e *PostmanExporter) Add(reqAndResp *RequestAndResponse) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key := reqAndResp.Key
+	if key == "" {
+		key = (&URLKeyStrategy{}).Key(reqAndResp)
+	}
+	item, ok := e.items[key]
+	if !ok {
+		item = e.newItem(reqAndResp)
+		e.items[key] = item
+	}
+
+	if resp := reqAndResp.Response; resp != nil {
+		for _, example := range item.Response {
+			if example.Code == resp.StatusCode {
+				return
+			}
+		}
+		item.Response = append(item.Response, e.newExample(item.Request, resp))
+	}
+}
+
+func (e *PostmanExporter) newItem(reqAndResp *RequestAndResponse) (item *PostmanItem) {
+	r := reqAndResp.Request
+	host, ok := e.hosts[r.URL.Host]
+	if !ok {
+		host = &postmanHost{
+			baseURL:  fmt.Sprintf("%s://%s", r.URL.Scheme, r.URL.Host),
+			variable: "baseUrl_" + strings.Trim(nonVariableChars.ReplaceAllString(r.URL.Host, "_"), "_"),
+			folders:  map[string]*PostmanItem{},
+		}
+		e.hosts[r.URL.Host] = host
+	}
+
+	segments := strings.FieldsFunc(r.URL.Path, func(c rune) bool {
+		return c == '/'
+	})
+	item = &PostmanItem{
+		Name: fmt.Sprintf("%s %s", r.Method, r.URL.Path),
+		Request: &PostmanRequest{
+			Method: r.Method,
+			Header: e.headers(r.Header),
+			URL: PostmanURL{
+				Raw:  "{{" + host.variable + "}}" + r.URL.EscapedPath(),
+				Host: []string{"{{" + host.variable + "}}"},
+				Path: segments,
+			},
+		},
+	}
+	if r.URL.RawQuery != "" {
+		item.Request.URL.Raw += "?" + r.URL.RawQuery
+		item.Request.URL.Query = toPostmanKeyValues(r.URL.Query())
+	}
+	item.Request.Body = newPostmanBody(r.Header.Get("Content-Type"), reqAndResp.ReadRequestBody())
+
+	// the path prefix is the folder, like /api/v1 of /api/v1/users
+	if len(segments) <= 1 {
+		host.items = append(host.items, item)
+	} else {
+		prefix := "/" + strings.Join(segments[:len(segments)-1], "/")
+		folder, ok := host.folders[prefix]
+		if !ok {
+			folder = &PostmanItem{Name: prefix}
+			host.folders[prefix] = folder
+		}
+		folder.Item = append(folder.Item, item)
+	}
+	return
+}
+
+func (e *PostmanExporter) headers(header http.Header) (pairs []PostmanKeyValue) {
+	pairs = []PostmanKeyValue{}
+	headers := e.headerPolicy.Apply(header)
+	for _, name := range SortedKeys(headers) {
+		value := headers[name]
+		if e.headerPolicy.IsSecret(name) && e.headerPolicy.Mode == SecretModeTemplate {
+			variable := GetSecretEnvName(name)
+			e.secrets[variable] = true
+			value = "{{" + variable + "}}"
+		}
+		pairs = append(pairs, PostmanKeyValue{Key: name, Value: value})
+	}
+	return
+}
+
+func (e *PostmanExporter) newExample(request *PostmanRequest, resp *SimpleResponse) (example *PostmanResponse) {
+	example = &PostmanResponse{
+		Name:            fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		OriginalRequest: request,
+		Status:          http.StatusText(resp.StatusCode),
+		Code:            resp.StatusCode,
+		Header:          toPostmanKeyValues(resp.Header),
+		Body:            resp.Body,
+	}
+
+	switch contentType := resp.Header.Get("Content-Type"); GetBodyKind(contentType) {
+	case BodyKindJSON:
+		example.PreviewLanguage = "json"
+	case BodyKindXML:
+		example.PreviewLanguage = "xml"
+	case BodyKindText, BodyKindForm:
+		example.PreviewLanguage = "text"
+	default:
+		if contentType != "" {
+			// the braces would be taken as the Postman variables
+			example.Body = SummarizeBinaryBody([]byte(resp.Body))
+		}
+	}
+	return
+}
+
+// Export exports the Postman collection as JSON
+func (e *PostmanExporter) Export() (string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	collection := PostmanCollection{
+		Info: PostmanInfo{Name: e.name, Schema: PostmanSchema},
+		Item: []*PostmanItem{},
+	}
+	for _, name := range SortedKeys(e.hosts) {
+		host := e.hosts[name]
+		hostFolder := &PostmanItem{Name: name}
+		for _, prefix := range SortedKeys(host.folders) {
+			hostFolder.Item = append(hostFolder.Item, host.folders[prefix])
+		}
+		hostFolder.Item = append(hostFolder.Item, host.items...)
+		collection.Item = append(collection.Item, hostFolder)
+		collection.Variable = append(collection.Variable, PostmanKeyValue{Key: host.variable, Value: host.baseURL, Type: "string"})
+	}
+	for _, secret := range SortedKeys(e.secrets) {
+		collection.Variable = append(collection.Variable, PostmanKeyValue{Key: secret, Type: "secret"})
+	}
+
+	data, err := json.MarshalIndent(collection, "", "  ")
+	return string(data), err
+}
+
+func newPostmanBody(contentType string, data []byte) (body *PostmanBody) {
+	if len(data) == 0 {
+		return
+	}
+
+	switch GetBodyKind(contentType) {
+	case BodyKindForm:
+		if form, err := ParseForm(contentType, data); err == nil {
+			body = &PostmanBody{Mode: "urlencoded", URLEncoded: toPostmanKeyValues(toValues(form))}
+			if getMediaType(contentType) == "multipart/form-data" {
+				body = &PostmanBody{Mode: "formdata", FormData: body.URLEncoded}
+			}
+			return
+		}
+	case BodyKindJSON:
+		return &PostmanBody{Mode: "raw", Raw: string(data), Options: &PostmanOptions{Raw: PostmanRawOptions{Language: "json"}}}
+	case BodyKindXML:
+		return &PostmanBody{Mode: "raw", Raw: string(data), Options: &PostmanOptions{Raw: PostmanRawOptions{Language: "xml"}}}
+	case BodyKindBinary:
+		if contentType != "" {
+			return &PostmanBody{Mode: "file", File: &PostmanFile{}}
+		}
+	}
+	return &PostmanBody{Mode: "raw", Raw: string(data)}
+}
+
+func toPostmanKeyValues(values map[string][]string) (pairs []PostmanKeyValue) {
+	pairs = []PostmanKeyValue{}
+	for _, pair := range toHARNameValues(values) {
+		pairs = append(pairs, PostmanKeyValue{Key: pair.Name, Value: pair.Value})
+	}
+	return
+}
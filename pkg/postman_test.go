@@ -0,0 +1,106 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostmanExporter(t *testing.T) {
+	collects := pkg.NewCollects()
+	exporter := pkg.NewPostmanExporter("sample")
+	collects.AddRawEvent(exporter.Add)
+
+	add := func(method, api, contentType, body string, code int) {
+		request, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+		request.Header.Set("Authorization", "Bearer token")
+		if contentType != "" {
+			request.Header.Set("Content-Type", contentType)
+		}
+		collects.Add(request, &pkg.SimpleResponse{
+			StatusCode: code,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       `{"name": "rick"}`,
+		})
+	}
+	add(http.MethodGet, "http://foo.com/api/v1/users?page=1", "", "", http.StatusOK)
+	add(http.MethodGet, "http://foo.com/api/v1/users?page=1", "", "", http.StatusOK)
+	add(http.MethodGet, "http://foo.com/api/v1/users?page=1", "", "", http.StatusUnauthorized)
+	add(http.MethodPost, "http://foo.com/api/v1/login", "application/x-www-form-urlencoded", "user=rick", http.StatusOK)
+	add(http.MethodPost, "https://bar.com:8443/health", "application/json", `{}`, http.StatusOK)
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+
+	collection := &pkg.PostmanCollection{}
+	assert.NoError(t, json.Unmarshal([]byte(result), collection))
+	assert.Equal(t, pkg.PostmanSchema, collection.Info.Schema)
+	assert.Equal(t, []pkg.PostmanKeyValue{
+		{Key: "baseUrl_bar_com_8443", Value: "https://bar.com:8443", Type: "string"},
+		{Key: "baseUrl_foo_com", Value: "http://foo.com", Type: "string"},
+		{Key: "AUTHORIZATION", Type: "secret"},
+	}, collection.Variable)
+
+	assert.Len(t, collection.Item, 2)
+	bar, foo := collection.Item[0], collection.Item[1]
+	assert.Equal(t, "bar.com:8443", bar.Name)
+	assert.Equal(t, "POST /health", bar.Item[0].Name)
+	assert.Equal(t, "json", bar.Item[0].Request.Body.Options.Raw.Language)
+
+	assert.Equal(t, "foo.com", foo.Name)
+	assert.Equal(t, "/api/v1", foo.Item[0].Name)
+	users, login := foo.Item[0].Item[0], foo.Item[0].Item[1]
+	assert.Equal(t, "{{baseUrl_foo_com}}/api/v1/users?page=1", users.Request.URL.Raw)
+	assert.Equal(t, []pkg.PostmanKeyValue{{Key: "page", Value: "1"}}, users.Request.URL.Query)
+	assert.Equal(t, []pkg.PostmanKeyValue{{Key: "Authorization", Value: "{{AUTHORIZATION}}"}}, users.Request.Header)
+	assert.Len(t, users.Response, 2)
+	assert.Equal(t, "401 Unauthorized", users.Response[1].Name)
+	assert.Equal(t, `{"name": "rick"}`, users.Response[0].Body)
+	assert.Equal(t, "json", users.Response[0].PreviewLanguage)
+
+	assert.Equal(t, "urlencoded", login.Request.Body.Mode)
+	assert.Equal(t, []pkg.PostmanKeyValue{{Key: "user", Value: "rick"}}, login.Request.Body.URLEncoded)
+}
+
+func TestPostmanBinaryBody(t *testing.T) {
+	exporter := pkg.NewPostmanExporter("sample")
+	request, _ := http.NewRequest(http.MethodPost, "http://foo.com/api/avatar", bytes.NewBufferString("\x89PNG\x00"))
+	request.Header.Set("Content-Type", "image/png")
+	exporter.Add(&pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"image/png"}},
+		Body:       "\x89PNG\x00",
+	}})
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+	// the binary bodies are not taken as the Postman variables
+	assert.NotContains(t, result, "{{/*")
+
+	collection := &pkg.PostmanCollection{}
+	assert.NoError(t, json.Unmarshal([]byte(result), collection))
+	avatar := collection.Item[0].Item[0].Item[0]
+	assert.Equal(t, "file", avatar.Request.Body.Mode)
+	assert.NotNil(t, avatar.Request.Body.File)
+	assert.Equal(t, pkg.SummarizeBinaryBody([]byte("\x89PNG\x00")), avatar.Response[0].Body)
+}
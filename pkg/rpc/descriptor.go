@@ -0,0 +1,118 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Descriptors keeps the proto descriptors to decode the gRPC messages
+type Descriptors struct {
+	files *protoregistry.Files
+}
+
+// NewDescriptors creates an empty descriptors
+func NewDescriptors() *Descriptors {
+	return &Descriptors{files: &protoregistry.Files{}}
+}
+
+// LoadProtoFiles parses the .proto files, the imports are searched in the import paths
+func (d *Descriptors) LoadProtoFiles(importPaths []string, files ...string) (err error) {
+	parser := protoparse.Parser{ImportPaths: importPaths}
+	fds, err := parser.ParseFiles(files...)
+	if err != nil {
+		return
+	}
+
+	for _, fd := range fds {
+		if err = d.register(fd.UnwrapFile()); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// LoadProtoSet reads a descriptor set which is generated by: protoc --descriptor_set_out --include_imports
+func (d *Descriptors) LoadProtoSet(file string) (err error) {
+	var data []byte
+	if data, err = os.ReadFile(file); err != nil {
+		return
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err = proto.Unmarshal(data, set); err != nil {
+		return
+	}
+
+	var files *protoregistry.Files
+	if files, err = protodesc.NewFiles(set); err != nil {
+		return
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = d.register(fd)
+		return err == nil
+	})
+	return
+}
+
+// register registers the file and its imports, the registered files are skipped
+func (d *Descriptors) register(fd protoreflect.FileDescriptor) (err error) {
+	if _, findErr := d.files.FindFileByPath(fd.Path()); findErr == nil {
+		return
+	}
+
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err = d.register(imports.Get(i).FileDescriptor); err != nil {
+			return
+		}
+	}
+	return d.files.RegisterFile(fd)
+}
+
+// FindMethod finds the method by the HTTP/2 path of gRPC, for example: /server.Runner/GetVersion
+func (d *Descriptors) FindMethod(path string) (method protoreflect.MethodDescriptor, err error) {
+	service, name, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		err = fmt.Errorf("invalid gRPC path %q", path)
+		return
+	}
+
+	var descriptor protoreflect.Descriptor
+	if descriptor, err = d.files.FindDescriptorByName(protoreflect.FullName(service)); err != nil {
+		return
+	}
+
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		err = fmt.Errorf("%q is not a service", service)
+		return
+	}
+	if method = serviceDescriptor.Methods().ByName(protoreflect.Name(name)); method == nil {
+		err = fmt.Errorf("method %q is not found in %q", name, service)
+	}
+	return
+}
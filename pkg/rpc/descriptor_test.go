@@ -0,0 +1,83 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/linuxsuren/atest-ext-collector/pkg/rpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestLoadProtoFiles(t *testing.T) {
+	descriptors := rpc.NewDescriptors()
+	assert.NoError(t, descriptors.LoadProtoFiles([]string{"testdata"}, "hello.proto"))
+	// the loaded files are skipped
+	assert.NoError(t, descriptors.LoadProtoFiles([]string{"testdata"}, "hello.proto"))
+
+	method, err := descriptors.FindMethod("/sample.Greeter/SayHello")
+	assert.NoError(t, err)
+	assert.Equal(t, "sample.HelloRequest", string(method.Input().FullName()))
+	assert.False(t, method.IsStreamingServer())
+
+	method, err = descriptors.FindMethod("/sample.Greeter/ListHellos")
+	assert.NoError(t, err)
+	assert.True(t, method.IsStreamingServer())
+
+	_, err = descriptors.FindMethod("/sample.Greeter/Fake")
+	assert.Error(t, err)
+	_, err = descriptors.FindMethod("/sample.HelloRequest/Fake")
+	assert.Error(t, err)
+	_, err = descriptors.FindMethod("/sample.Fake/SayHello")
+	assert.Error(t, err)
+	_, err = descriptors.FindMethod("invalid")
+	assert.Error(t, err)
+
+	assert.Error(t, rpc.NewDescriptors().LoadProtoFiles(nil, "testdata/fake.proto"))
+}
+
+func TestLoadProtoSet(t *testing.T) {
+	parser := protoparse.Parser{ImportPaths: []string{"testdata"}}
+	fds, err := parser.ParseFiles("hello.proto")
+	assert.NoError(t, err)
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, dep := range fds[0].GetDependencies() {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(dep.UnwrapFile()))
+	}
+	set.File = append(set.File, protodesc.ToFileDescriptorProto(fds[0].UnwrapFile()))
+	data, err := proto.Marshal(set)
+	assert.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "hello.protoset")
+	assert.NoError(t, os.WriteFile(file, data, 0644))
+
+	descriptors := rpc.NewDescriptors()
+	assert.NoError(t, descriptors.LoadProtoSet(file))
+	_, err = descriptors.FindMethod("/sample.Greeter/SayHello")
+	assert.NoError(t, err)
+
+	assert.Error(t, descriptors.LoadProtoSet(filepath.Join(t.TempDir(), "fake")))
+	assert.NoError(t, os.WriteFile(file, []byte("invalid"), 0644))
+	assert.Error(t, descriptors.LoadProtoSet(file))
+}
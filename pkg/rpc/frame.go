@@ -0,0 +1,109 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// frameHeaderSize is the size of the compressed flag and the message length
+const frameHeaderSize = 5
+
+// DecodeFrames splits the gRPC length-prefixed messages, the compressed ones are decompressed by the encoding.
+// The incomplete frame at the end is ignored, it happens when the stream is cancelled.
+func DecodeFrames(data []byte, encoding string) (messages [][]byte, err error) {
+	for len(data) >= frameHeaderSize {
+		compressed := data[0] == 1
+		length := int(binary.BigEndian.Uint32(data[1:frameHeaderSize]))
+		if len(data) < frameHeaderSize+length {
+			break
+		}
+
+		message := data[frameHeaderSize : frameHeaderSize+length]
+		data = data[frameHeaderSize+length:]
+		if compressed {
+			if message, err = decompress(message, encoding); err != nil {
+				return
+			}
+		}
+		messages = append(messages, message)
+	}
+	return
+}
+
+// EncodeFrame encodes a message as an uncompressed gRPC frame
+func EncodeFrame(message []byte) []byte {
+	frame := make([]byte, frameHeaderSize+len(message))
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(len(message)))
+	copy(frame[frameHeaderSize:], message)
+	return frame
+}
+
+func decompress(message []byte, encoding string) (data []byte, err error) {
+	switch encoding {
+	case "gzip":
+		var reader *gzip.Reader
+		if reader, err = gzip.NewReader(bytes.NewReader(message)); err == nil {
+			data, err = io.ReadAll(reader)
+		}
+	default:
+		err = fmt.Errorf("unsupported gRPC encoding %q", encoding)
+	}
+	return
+}
+
+// ToJSON converts the messages to JSON, it is an array if the messages are streaming
+func ToJSON(descriptor protoreflect.MessageDescriptor, messages [][]byte, streaming bool) (result string, err error) {
+	items := make([]json.RawMessage, 0, len(messages))
+	for _, data := range messages {
+		message := dynamicpb.NewMessage(descriptor)
+		if err = proto.Unmarshal(data, message); err != nil {
+			return
+		}
+
+		var item []byte
+		if item, err = protojson.Marshal(message); err != nil {
+			return
+		}
+		items = append(items, item)
+	}
+
+	var value interface{} = items
+	if !streaming {
+		if len(items) == 0 {
+			return
+		}
+		value = items[0]
+	}
+
+	// protojson does not guarantee the stable output, it is formatted again
+	var data []byte
+	if data, err = json.MarshalIndent(value, "", "  "); err == nil {
+		result = string(data)
+	}
+	return
+}
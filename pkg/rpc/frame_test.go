@@ -0,0 +1,98 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/rpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestDecodeFrames(t *testing.T) {
+	data := append(rpc.EncodeFrame([]byte("hello")), rpc.EncodeFrame(nil)...)
+	// the incomplete frame is ignored
+	data = append(data, 0, 0, 0, 0, 9, 'a')
+
+	messages, err := rpc.DecodeFrames(data, "")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("hello"), {}}, messages)
+
+	buf := new(bytes.Buffer)
+	writer := gzip.NewWriter(buf)
+	_, _ = writer.Write([]byte("compressed"))
+	assert.NoError(t, writer.Close())
+	frame := make([]byte, 5)
+	frame[0] = 1
+	binary.BigEndian.PutUint32(frame[1:], uint32(buf.Len()))
+	frame = append(frame, buf.Bytes()...)
+
+	messages, err = rpc.DecodeFrames(frame, "gzip")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("compressed")}, messages)
+
+	_, err = rpc.DecodeFrames(frame, "snappy")
+	assert.Error(t, err)
+}
+
+func TestToJSON(t *testing.T) {
+	method := findMethod(t, "/sample.Greeter/ListHellos")
+	first := marshalMessage(t, method.Output(), `{"message": "hello"}`)
+	second := marshalMessage(t, method.Output(), `{"message": "world", "time": "2026-01-02T03:04:05Z"}`)
+
+	result, err := rpc.ToJSON(method.Output(), [][]byte{first}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"message\": \"hello\"\n}", result)
+
+	result, err = rpc.ToJSON(method.Output(), [][]byte{first, second}, true)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"message": "hello"}, {"message": "world", "time": "2026-01-02T03:04:05Z"}]`, result)
+
+	result, err = rpc.ToJSON(method.Output(), nil, false)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	result, err = rpc.ToJSON(method.Output(), nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", result)
+
+	_, err = rpc.ToJSON(method.Output(), [][]byte{{0xff}}, false)
+	assert.Error(t, err)
+}
+
+func findMethod(t *testing.T, path string) protoreflect.MethodDescriptor {
+	descriptors := rpc.NewDescriptors()
+	assert.NoError(t, descriptors.LoadProtoFiles([]string{"testdata"}, "hello.proto"))
+	method, err := descriptors.FindMethod(path)
+	assert.NoError(t, err)
+	return method
+}
+
+func marshalMessage(t *testing.T, descriptor protoreflect.MessageDescriptor, text string) []byte {
+	message := dynamicpb.NewMessage(descriptor)
+	assert.NoError(t, protojson.Unmarshal([]byte(text), message))
+	data, err := proto.Marshal(message)
+	assert.NoError(t, err)
+	return data
+}
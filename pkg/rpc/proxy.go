@@ -0,0 +1,268 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// ContentType is the content type of the gRPC requests, it might have a suffix like application/grpc+proto
+const ContentType = "application/grpc"
+
+// AddFunc receives the decoded gRPC calls, the bodies are JSON, it is the same as Collects.Add
+type AddFunc func(req *http.Request, resp *pkg.SimpleResponse)
+
+// IsGRPC returns true if it is a gRPC request
+func IsGRPC(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), ContentType)
+}
+
+// Proxy is a reverse proxy of a plaintext (h2c) gRPC server, or of the intercepted HTTPS
+// servers. It decodes the calls with the proto descriptors, the other requests are
+// forwarded without capturing.
+type Proxy struct {
+	target      *url.URL
+	descriptors *Descriptors
+	add         AddFunc
+	proxy       *httputil.ReverseProxy
+	maxBodySize int64
+}
+
+// NewProxy creates a gRPC proxy of the target, for example: localhost:7070
+func NewProxy(target string, descriptors *Descriptors, add AddFunc) (p *Proxy, err error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	p = &Proxy{descriptors: descriptors, add: add}
+	if p.target, err = url.Parse(target); err != nil {
+		return
+	}
+
+	p.proxy = httputil.NewSingleHostReverseProxy(p.target)
+	// the streaming messages should be forwarded immediately
+	p.proxy.FlushInterval = -1
+	p.proxy.Transport = &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+	p.proxy.ModifyResponse = p.modifyResponse
+	return
+}
+
+// NewMITMProxy creates a gRPC proxy of the intercepted HTTPS connections, the requests have the absolute
+// URLs of the servers. The TLS config and the dial function are the same as the HTTP proxy's, the dial
+// function connects to the upstream proxy, it is optional.
+func NewMITMProxy(descriptors *Descriptors, add AddFunc, config *tls.Config,
+	dial func(network, addr string) (net.Conn, error)) (p *Proxy) {
+	transport := &http2.Transport{TLSClientConfig: config}
+	if dial != nil {
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, config *tls.Config) (net.Conn, error) {
+			conn, err := dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			tlsConn := tls.Client(conn, config)
+			if err = tlsConn.HandshakeContext(ctx); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		}
+	}
+
+	p = &Proxy{descriptors: descriptors, add: add}
+	p.proxy = &httputil.ReverseProxy{
+		// the URLs are absolute already
+		Director:       func(*http.Request) {},
+		Transport:      transport,
+		FlushInterval:  -1,
+		ModifyResponse: p.modifyResponse,
+	}
+	return
+}
+
+// WithMaxBodySize sets the max size of the recorded request and response bodies, the messages are
+// still forwarded after it. There is no limit if it is not positive.
+func (p *Proxy) WithMaxBodySize(limit int64) *Proxy {
+	p.maxBodySize = limit
+	return p
+}
+
+// Handler returns the HTTP handler which accepts the HTTP/2 requests without TLS
+func (p *Proxy) Handler() http.Handler {
+	return h2c.NewHandler(p, &http2.Server{})
+}
+
+type call struct {
+	startedAt   time.Time
+	request     *http.Request
+	requestBody *lockedBuffer
+}
+
+type callKey struct{}
+
+// ServeHTTP forwards the request and keeps a copy of the request body
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !IsGRPC(r) {
+		p.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	c := &call{startedAt: time.Now(), request: r, requestBody: &lockedBuffer{limit: p.maxBodySize}}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &teeReadCloser{ReadCloser: r.Body, writer: c.requestBody}
+	}
+	p.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callKey{}, c)))
+}
+
+// modifyResponse captures the response body, the trailers are available after reading all of it
+func (p *Proxy) modifyResponse(resp *http.Response) (err error) {
+	c, ok := resp.Request.Context().Value(callKey{}).(*call)
+	if !ok {
+		return
+	}
+
+	buf := &lockedBuffer{limit: p.maxBodySize}
+	var once sync.Once
+	resp.Body = &teeReadCloser{ReadCloser: resp.Body, writer: buf, onClose: func() {
+		once.Do(func() {
+			p.record(c, resp, buf)
+		})
+	}}
+	return
+}
+
+func (p *Proxy) record(c *call, resp *http.Response, responseBody *lockedBuffer) {
+	r := c.request
+	method, err := p.descriptors.FindMethod(r.URL.Path)
+	if err != nil {
+		log.Println("skip the gRPC call", r.URL.Path, err)
+		return
+	}
+
+	// the incomplete frame of a truncated body is dropped, the complete messages are recorded
+	requestBody, requestTruncated := c.requestBody.Bytes()
+	responseData, responseTruncated := responseBody.Bytes()
+	if requestTruncated || responseTruncated {
+		log.Println("the gRPC call is truncated", r.URL.Path)
+	}
+
+	var requestJSON, responseJSON string
+	var messages [][]byte
+	if messages, err = DecodeFrames(requestBody, r.Header.Get("Grpc-Encoding")); err == nil {
+		requestJSON, err = ToJSON(method.Input(), messages, method.IsStreamingClient())
+	}
+	if err == nil {
+		if messages, err = DecodeFrames(responseData, resp.Header.Get("Grpc-Encoding")); err == nil {
+			responseJSON, err = ToJSON(method.Output(), messages, method.IsStreamingServer())
+		}
+	}
+	if err != nil {
+		log.Println("failed to decode the gRPC call", r.URL.Path, err)
+		return
+	}
+
+	// the status is in the trailers, or in the headers if there is no message
+	header := resp.Header.Clone()
+	for key, values := range resp.Trailer {
+		header[key] = values
+	}
+
+	req := r.Clone(context.Background())
+	if p.target != nil {
+		req.URL.Scheme = p.target.Scheme
+		req.URL.Host = p.target.Host
+	}
+	req.Body = io.NopCloser(strings.NewReader(requestJSON))
+	p.add(req, &pkg.SimpleResponse{
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       responseJSON,
+		Truncated:  requestTruncated || responseTruncated,
+		StartedAt:  c.startedAt,
+		Duration:   time.Since(c.startedAt),
+	})
+}
+
+// lockedBuffer is a buffer which is written and read in the different goroutines, it keeps
+// the data up to the limit if it is positive
+type lockedBuffer struct {
+	lock      sync.Mutex
+	buf       bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+// Write always accepts all the data, then the forwarding is not interrupted by the limit
+func (b *lockedBuffer) Write(data []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	chunk := data
+	if room := b.limit - int64(b.buf.Len()); b.limit > 0 && room < int64(len(chunk)) {
+		chunk = chunk[:room]
+		b.truncated = true
+	}
+	b.buf.Write(chunk)
+	return len(data), nil
+}
+
+// Bytes returns a copy of the data, and whether it is over the limit
+func (b *lockedBuffer) Bytes() ([]byte, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...), b.truncated
+}
+
+type teeReadCloser struct {
+	io.ReadCloser
+	writer  io.Writer
+	onClose func()
+}
+
+func (t *teeReadCloser) Read(data []byte) (n int, err error) {
+	n, err = t.ReadCloser.Read(data)
+	if n > 0 {
+		_, _ = t.writer.Write(data[:n])
+	}
+	return
+}
+
+func (t *teeReadCloser) Close() (err error) {
+	err = t.ReadCloser.Close()
+	if t.onClose != nil {
+		t.onClose()
+	}
+	return
+}
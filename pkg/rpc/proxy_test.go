@@ -0,0 +1,203 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/rpc"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type call struct {
+	req  *http.Request
+	resp *pkg.SimpleResponse
+}
+
+func TestProxy(t *testing.T) {
+	method := findMethod(t, "/sample.Greeter/ListHellos")
+	reply := marshalMessage(t, method.Output(), `{"message": "hello rick"}`)
+
+	backend := httptest.NewServer(h2c.NewHandler(newGreeter(reply), &http2.Server{}))
+	defer backend.Close()
+
+	calls := make(chan call, 3)
+	proxy, err := rpc.NewProxy(backend.Listener.Addr().String(), loadDescriptors(t), func(req *http.Request, resp *pkg.SimpleResponse) {
+		calls <- call{req: req, resp: resp}
+	})
+	assert.NoError(t, err)
+	server := httptest.NewServer(proxy.Handler())
+	defer server.Close()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	request := marshalMessage(t, method.Input(), `{"name": "rick", "times": 2}`)
+	invoke := func(path string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewReader(rpc.EncodeFrame(request)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", rpc.ContentType)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		_, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		resp := invoke("/sample.Greeter/SayHello")
+		assert.Equal(t, "0", resp.Trailer.Get("Grpc-Status"))
+
+		c := receive(t, calls)
+		assert.Equal(t, "http://"+backend.Listener.Addr().String()+"/sample.Greeter/SayHello", c.req.URL.String())
+		assert.Equal(t, "Bearer token", c.req.Header.Get("Authorization"))
+		body, _ := io.ReadAll(c.req.Body)
+		assert.JSONEq(t, `{"name": "rick", "times": 2}`, string(body))
+		assert.JSONEq(t, `{"message": "hello rick"}`, c.resp.Body)
+		assert.Equal(t, "0", c.resp.Header.Get("Grpc-Status"))
+	})
+
+	t.Run("server streaming", func(t *testing.T) {
+		invoke("/sample.Greeter/ListHellos")
+
+		c := receive(t, calls)
+		assert.JSONEq(t, `[{"message": "hello rick"}, {"message": "hello rick"}]`, c.resp.Body)
+		assert.Equal(t, "5", c.resp.Header.Get("Grpc-Status"))
+		assert.Equal(t, "no more hellos", c.resp.Header.Get("Grpc-Message"))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		// only the first message of the stream is kept
+		limited, err := rpc.NewProxy(backend.Listener.Addr().String(), loadDescriptors(t), func(req *http.Request, resp *pkg.SimpleResponse) {
+			calls <- call{req: req, resp: resp}
+		})
+		assert.NoError(t, err)
+		limitedServer := httptest.NewServer(limited.WithMaxBodySize(int64(len(rpc.EncodeFrame(reply)) + 1)).Handler())
+		defer limitedServer.Close()
+
+		req, err := http.NewRequest(http.MethodPost, limitedServer.URL+"/sample.Greeter/ListHellos",
+			bytes.NewReader(rpc.EncodeFrame(request)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", rpc.ContentType)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.Equal(t, 2*len(rpc.EncodeFrame(reply)), len(data))
+
+		c := receive(t, calls)
+		assert.True(t, c.resp.Truncated)
+		assert.JSONEq(t, `[{"message": "hello rick"}]`, c.resp.Body)
+		body, _ := io.ReadAll(c.req.Body)
+		assert.JSONEq(t, `{"name": "rick", "times": 2}`, string(body))
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		invoke("/sample.Greeter/Fake")
+		select {
+		case c := <-calls:
+			t.Fatalf("unexpected call %s", c.req.URL)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("not gRPC", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/healthz")
+		assert.NoError(t, err)
+		_, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.Equal(t, "12", resp.Trailer.Get("Grpc-Status"))
+		assert.Empty(t, calls)
+	})
+}
+
+func TestMITMProxy(t *testing.T) {
+	method := findMethod(t, "/sample.Greeter/SayHello")
+	reply := marshalMessage(t, method.Output(), `{"message": "hello rick"}`)
+	backend := httptest.NewUnstartedServer(newGreeter(reply))
+	backend.EnableHTTP2 = true
+	backend.StartTLS()
+	defer backend.Close()
+
+	calls := make(chan call, 1)
+	proxy := rpc.NewMITMProxy(loadDescriptors(t), func(req *http.Request, resp *pkg.SimpleResponse) {
+		calls <- call{req: req, resp: resp}
+	}, backend.Client().Transport.(*http.Transport).TLSClientConfig, nil)
+
+	request := marshalMessage(t, method.Input(), `{"name": "rick"}`)
+	req := httptest.NewRequest(http.MethodPost, backend.URL+"/sample.Greeter/SayHello", bytes.NewReader(rpc.EncodeFrame(request)))
+	req.Header.Set("Content-Type", rpc.ContentType)
+	assert.True(t, rpc.IsGRPC(req))
+	recorder := httptest.NewRecorder()
+	proxy.ServeHTTP(recorder, req)
+	assert.Equal(t, "0", recorder.Result().Trailer.Get("Grpc-Status"))
+
+	c := receive(t, calls)
+	assert.Equal(t, backend.URL+"/sample.Greeter/SayHello", c.req.URL.String())
+	assert.JSONEq(t, `{"message": "hello rick"}`, c.resp.Body)
+}
+
+// newGreeter creates a fake gRPC server which replies the messages and the status in the trailers
+func newGreeter(reply []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", rpc.ContentType)
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		switch r.URL.Path {
+		case "/sample.Greeter/SayHello":
+			_, _ = w.Write(rpc.EncodeFrame(reply))
+			w.Header().Set("Grpc-Status", "0")
+		case "/sample.Greeter/ListHellos":
+			_, _ = w.Write(rpc.EncodeFrame(reply))
+			_, _ = w.Write(rpc.EncodeFrame(reply))
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "no more hellos")
+		default:
+			w.Header().Set("Grpc-Status", "12")
+		}
+	})
+}
+
+func loadDescriptors(t *testing.T) *rpc.Descriptors {
+	descriptors := rpc.NewDescriptors()
+	assert.NoError(t, descriptors.LoadProtoFiles([]string{"testdata"}, "hello.proto"))
+	return descriptors
+}
+
+func receive(t *testing.T, calls chan call) (c call) {
+	select {
+	case c = <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("the gRPC call is not captured")
+	}
+	return
+}
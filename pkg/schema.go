@@ -0,0 +1,216 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+)
+
+// JSONSchemaDraft is the JSON Schema version of the inferred schemas
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a subset of the JSON Schema which could be inferred from the JSON values
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Type       SchemaTypes        `json:"type,omitempty" yaml:"type,omitempty"`
+	Format     string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// SchemaTypes are the types of a schema, it is a string in JSON if there is only one type
+type SchemaTypes []string
+
+// MarshalJSON implements the json.Marshaler
+func (t SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// MarshalYAML implements the yaml.Marshaler
+func (t SchemaTypes) MarshalYAML() (interface{}, error) {
+	if len(t) == 1 {
+		return t[0], nil
+	}
+	return []string(t), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler
+func (t *SchemaTypes) UnmarshalJSON(data []byte) (err error) {
+	var single string
+	if err = json.Unmarshal(data, &single); err == nil {
+		*t = SchemaTypes{single}
+		return
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler
+func (t *SchemaTypes) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var single string
+	if err = unmarshal(&single); err == nil {
+		*t = SchemaTypes{single}
+		return
+	}
+	return unmarshal((*[]string)(t))
+}
+
+// InferSchema infers the schema of a JSON value
+func InferSchema(value interface{}) (schema *Schema) {
+	schema = &Schema{}
+	switch val := value.(type) {
+	case map[string]interface{}:
+		schema.Type = SchemaTypes{"object"}
+		schema.Properties = make(map[string]*Schema, len(val))
+		for key, item := range val {
+			schema.Properties[key] = InferSchema(item)
+			schema.Required = append(schema.Required, key)
+		}
+		sort.Strings(schema.Required)
+	case []interface{}:
+		schema.Type = SchemaTypes{"array"}
+		for _, item := range val {
+			schema.Items = MergeSchema(schema.Items, InferSchema(item))
+		}
+	case string:
+		schema.Type = SchemaTypes{"string"}
+	case bool:
+		schema.Type = SchemaTypes{"boolean"}
+	case float64:
+		if val == math.Trunc(val) {
+			schema.Type = SchemaTypes{"integer"}
+		} else {
+			schema.Type = SchemaTypes{"number"}
+		}
+	case nil:
+		schema.Type = SchemaTypes{"null"}
+	}
+	return
+}
+
+// MergeSchema merges two schemas into a new one which accepts the values of both of them.
+// The types are united, and only the properties which exist in both of them are required.
+func MergeSchema(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	merged := &Schema{Schema: a.Schema, Type: mergeSchemaTypes(a.Type, b.Type)}
+	if a.Format == b.Format {
+		merged.Format = a.Format
+	}
+	if a.Properties != nil || b.Properties != nil {
+		merged.Properties = map[string]*Schema{}
+		for key, item := range a.Properties {
+			merged.Properties[key] = item
+		}
+		for key, item := range b.Properties {
+			merged.Properties[key] = MergeSchema(merged.Properties[key], item)
+		}
+	}
+	merged.Required = mergeRequired(a, b)
+	merged.Items = MergeSchema(a.Items, b.Items)
+	return merged
+}
+
+func mergeSchemaTypes(a, b SchemaTypes) (types SchemaTypes) {
+	set := map[string]bool{}
+	for _, item := range append(append(SchemaTypes{}, a...), b...) {
+		set[item] = true
+	}
+	// integer is a subset of number
+	if set["number"] {
+		delete(set, "integer")
+	}
+	for item := range set {
+		types = append(types, item)
+	}
+	sort.Strings(types)
+	return
+}
+
+// mergeRequired returns the required properties of both schemas,
+// the object which is observed in one schema only keeps its required properties
+func mergeRequired(a, b *Schema) (required []string) {
+	if a.Properties == nil {
+		return b.Required
+	}
+	if b.Properties == nil {
+		return a.Required
+	}
+
+	set := map[string]bool{}
+	for _, key := range b.Required {
+		set[key] = true
+	}
+	for _, key := range a.Required {
+		if set[key] {
+			required = append(required, key)
+		}
+	}
+	return
+}
+
+// SchemaInferrer infers the JSON schemas of the response bodies, the bodies of the same key are merged.
+// It should be added as a raw event handle, then all the duplicated requests are observed.
+type SchemaInferrer struct {
+	lock    sync.RWMutex
+	schemas map[string]*Schema
+}
+
+// NewSchemaInferrer creates a schema inferrer
+func NewSchemaInferrer() *SchemaInferrer {
+	return &SchemaInferrer{schemas: map[string]*Schema{}}
+}
+
+// Add is the EventHandle of the schema inferrer, the non-JSON responses are ignored
+func (i *SchemaInferrer) Add(reqAndResp *RequestAndResponse) {
+	resp := reqAndResp.Response
+	if resp == nil || resp.Body == "" || GetBodyKind(resp.Header.Get("Content-Type")) != BodyKindJSON {
+		return
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(resp.Body), &data); err != nil {
+		return
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.schemas[reqAndResp.Key] = MergeSchema(i.schemas[reqAndResp.Key], InferSchema(data))
+}
+
+// Get returns the schema of a key, it returns nil if there is no JSON response
+func (i *SchemaInferrer) Get(key string) (schema *Schema) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	if inferred, ok := i.schemas[key]; ok {
+		clone := *inferred
+		clone.Schema = JSONSchemaDraft
+		schema = &clone
+	}
+	return
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInferSchema(t *testing.T) {
+	var first, second interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": 1, "name": "rick", "tags": ["a"], "extra": {"age": 18}}`), &first))
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": 2.5, "name": null, "tags": []}`), &second))
+
+	schema := pkg.MergeSchema(pkg.InferSchema(first), pkg.InferSchema(second))
+	data, err := json.Marshal(schema)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "number"},
+			"name": {"type": ["null", "string"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"extra": {"type": "object", "properties": {"age": {"type": "integer"}}, "required": ["age"]}
+		},
+		"required": ["id", "name", "tags"]
+	}`, string(data))
+
+	decoded := &pkg.Schema{}
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, schema, decoded)
+}
+
+func TestSchemaInferrer(t *testing.T) {
+	collects := pkg.NewCollects()
+	schemas := pkg.NewSchemaInferrer()
+	collects.AddRawEvent(schemas.Add)
+	exporter := pkg.NewSampleExporter(false).WithSchemas(schemas, "")
+
+	done := make(chan struct{})
+	collects.AddEvent(func(r *pkg.RequestAndResponse) {
+		exporter.Add(r)
+		done <- struct{}{}
+	})
+	defer collects.Stop()
+
+	bodies := []string{`{"name": "rick", "age": 18}`, `{"name": "linuxsuren"}`, "not json"}
+	for _, body := range bodies {
+		request, _ := http.NewRequest(http.MethodGet, "http://foo/api/v1/users", nil)
+		collects.Add(request, &pkg.SimpleResponse{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       body,
+		})
+	}
+	<-done
+
+	items := exporter.TestSuite.Items
+	assert.Len(t, items, 1)
+	schema := schemas.Get(items[0].Request.Method + "-" + items[0].Request.API)
+	assert.Equal(t, pkg.JSONSchemaDraft, schema.Schema)
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.Nil(t, schemas.Get("fake"))
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+	assert.Contains(t, result, "schema: |-")
+
+	dir := t.TempDir()
+	exporter.WithSchemas(schemas, filepath.Join(dir, "schemas")).WithOutputDir(dir)
+	result, err = exporter.Export()
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "schemas", "users.json"))
+
+	suite := &atest.TestSuite{}
+	assert.NoError(t, yaml.Unmarshal([]byte(result), suite))
+	// the schema is referenced relative to the output directory
+	assert.Equal(t, `{"$ref": "file://./schemas/users.json"}`, suite.Items[0].Expect.Schema)
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+	for _, body := range bodies[:2] {
+		validation, err := gojsonschema.Validate(gojsonschema.NewStringLoader(suite.Items[0].Expect.Schema),
+			gojsonschema.NewStringLoader(body))
+		assert.NoError(t, err)
+		assert.True(t, validation.Valid(), body)
+	}
+	validation, err := gojsonschema.Validate(gojsonschema.NewStringLoader(suite.Items[0].Expect.Schema),
+		gojsonschema.NewStringLoader(`{"age": "18"}`))
+	assert.NoError(t, err)
+	assert.False(t, validation.Valid())
+}
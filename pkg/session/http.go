@@ -0,0 +1,179 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+)
+
+// APIPrefix is the path prefix of the control API
+const APIPrefix = "/api/v1/sessions"
+
+// exportTimeout is the max time to wait for the pending requests when downloading a test suite
+const exportTimeout = 5 * time.Second
+
+// StartRequest is the body to start a session or to set its filter
+type StartRequest struct {
+	Name       string       `json:"name"`
+	FilterPath []string     `json:"filterPath"`
+	Filter     *filter.Rule `json:"filter"`
+}
+
+type errorReply struct {
+	Error string `json:"error"`
+}
+
+// NewHandler creates the HTTP handler of the control API, the routes are:
+//
+//	GET    /api/v1/sessions                  lists the sessions
+//	POST   /api/v1/sessions                  starts a session
+//	GET    /api/v1/sessions/{name}           gets a session
+//	DELETE /api/v1/sessions/{name}           stops and removes a session
+//	POST   /api/v1/sessions/{name}/pause     pauses a session
+//	POST   /api/v1/sessions/{name}/resume    resumes a session
+//	POST   /api/v1/sessions/{name}/stop      stops a session, the test suite is kept
+//	PUT    /api/v1/sessions/{name}/filter    sets the filter of a session
+//	GET    /api/v1/sessions/{name}/requests  lists the recorded requests
+//	GET    /api/v1/sessions/{name}/suite     downloads the test suite
+func NewHandler(manager *Manager) http.Handler {
+	handler := &httpHandler{manager: manager}
+	mux := http.NewServeMux()
+	mux.HandleFunc(APIPrefix, handler.sessions)
+	mux.HandleFunc(APIPrefix+"/", handler.session)
+	return mux
+}
+
+type httpHandler struct {
+	manager *Manager
+}
+
+func (h *httpHandler) sessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		infos := []Info{}
+		for _, session := range h.manager.List() {
+			infos = append(infos, session.Info())
+		}
+		writeJSON(w, http.StatusOK, infos)
+	case http.MethodPost:
+		body := StartRequest{}
+		if err := decodeJSON(r, &body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if session, err := h.manager.Start(body.Name, body.FilterPath, body.Filter); err != nil {
+			writeError(w, statusOf(err), err)
+		} else {
+			writeJSON(w, http.StatusCreated, session.Info())
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+	}
+}
+
+func (h *httpHandler) session(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, APIPrefix+"/"), "/")
+	session, err := h.manager.Get(name)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	switch route := r.Method + " " + action; route {
+	case "GET ":
+		writeJSON(w, http.StatusOK, session.Info())
+	case "DELETE ":
+		if err = h.manager.Delete(name); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case "POST pause", "POST resume", "POST stop":
+		switch action {
+		case "pause":
+			err = session.Pause()
+		case "resume":
+			err = session.Resume()
+		default:
+			err = session.Stop()
+		}
+		if err == nil {
+			writeJSON(w, http.StatusOK, session.Info())
+		}
+	case "PUT filter":
+		body := StartRequest{}
+		if err = decodeJSON(r, &body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err = session.SetFilter(body.FilterPath, body.Filter); err == nil {
+			writeJSON(w, http.StatusOK, session.Info())
+		}
+	case "GET requests":
+		writeJSON(w, http.StatusOK, session.Requests())
+	case "GET suite":
+		ctx, cancel := context.WithTimeout(r.Context(), exportTimeout)
+		defer cancel()
+		var suite string
+		if suite, err = session.Export(ctx); err == nil {
+			w.Header().Set("Content-Type", "text/yaml")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".yaml"))
+			_, _ = w.Write([]byte(suite))
+		}
+	default:
+		err = fmt.Errorf("%w: %s %s", errNoRoute, r.Method, r.URL.Path)
+	}
+	if err != nil {
+		writeError(w, statusOf(err), err)
+	}
+}
+
+var errNoRoute = errors.New("no route")
+
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, errNoRoute):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// decodeJSON rejects the unknown fields, then the typos of the filters do not match everything
+func decodeJSON(r *http.Request, data interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(data)
+}
+
+func writeJSON(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorReply{Error: err.Error()})
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	manager := session.NewManager(session.Options{})
+	defer func() {
+		_ = manager.Stop()
+	}()
+	server := httptest.NewServer(session.NewHandler(manager))
+	defer server.Close()
+
+	call := func(method, path, body string) (int, string) {
+		request, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		resp, err := http.DefaultClient.Do(request)
+		if !assert.NoError(t, err) {
+			return 0, ""
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+	info := func(data string) (result session.Info) {
+		assert.NoError(t, json.Unmarshal([]byte(data), &result))
+		return
+	}
+
+	code, data := call(http.MethodPost, "/api/v1/sessions", `{"name":"users","filterPath":["/api/users"]}`)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, session.StateRecording, info(data).State)
+	code, _ = call(http.MethodPost, "/api/v1/sessions", `{"name":"users"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = call(http.MethodPost, "/api/v1/sessions", `invalid`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = call(http.MethodPut, "/api/v1/sessions", ``)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, data = call(http.MethodGet, "/api/v1/sessions", "")
+	assert.Equal(t, http.StatusOK, code)
+	var infos []session.Info
+	assert.NoError(t, json.Unmarshal([]byte(data), &infos))
+	assert.Len(t, infos, 1)
+
+	add(manager, http.MethodGet, "http://foo/api/users", "")
+	code, data = call(http.MethodGet, "/api/v1/sessions/users/requests", "")
+	assert.Equal(t, http.StatusOK, code)
+	var requests []session.Request
+	assert.NoError(t, json.Unmarshal([]byte(data), &requests))
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "http://foo/api/users", requests[0].URL)
+	}
+
+	code, data = call(http.MethodPost, "/api/v1/sessions/users/pause", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, session.StatePaused, info(data).State)
+	code, _ = call(http.MethodPost, "/api/v1/sessions/users/pause", "")
+	assert.Equal(t, http.StatusConflict, code)
+	code, data = call(http.MethodPost, "/api/v1/sessions/users/resume", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, session.StateRecording, info(data).State)
+
+	code, data = call(http.MethodPut, "/api/v1/sessions/users/filter", `{"filterPath":["/api/orders"],"filter":{"method":["POST"]}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"/api/orders"}, info(data).FilterPath)
+	if filter := info(data).Filter; assert.NotNil(t, filter) {
+		assert.Equal(t, []string{"POST"}, filter.Method)
+	}
+	code, _ = call(http.MethodPut, "/api/v1/sessions/users/filter", `{"filter":{"status":["fake"]}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = call(http.MethodPut, "/api/v1/sessions/users/filter", `invalid`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = call(http.MethodPut, "/api/v1/sessions/users/filter", `{"filter":{"methods":["POST"]}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, data = call(http.MethodPost, "/api/v1/sessions/users/stop", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, session.StateStopped, info(data).State)
+
+	code, data = call(http.MethodGet, "/api/v1/sessions/users/suite", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, data, "api: http://foo/api/users")
+
+	code, data = call(http.MethodGet, "/api/v1/sessions/users", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "users", info(data).Name)
+	code, _ = call(http.MethodGet, "/api/v1/sessions/users/fake", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = call(http.MethodDelete, "/api/v1/sessions/users", "")
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = call(http.MethodGet, "/api/v1/sessions/users", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package session manages the named recording sessions, they could be controlled without restarting the proxy.
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+)
+
+// State is the state of a session
+type State string
+
+const (
+	// StateRecording records the matched requests
+	StateRecording State = "recording"
+	// StatePaused ignores all the requests until it is resumed
+	StatePaused State = "paused"
+	// StateStopped never records again, the recorded requests are kept
+	StateStopped State = "stopped"
+)
+
+// MaxRequests is the max number of the recent requests which are kept in a session
+const MaxRequests = 1000
+
+var (
+	// ErrNotFound means the session does not exist
+	ErrNotFound = errors.New("session not found")
+	// ErrConflict means the session exists already, or it is in the wrong state
+	ErrConflict = errors.New("session conflict")
+)
+
+// Info is the summary of a session
+type Info struct {
+	Name       string            `json:"name"`
+	State      State             `json:"state"`
+	FilterPath []string          `json:"filterPath"`
+	Filter     *filter.Rule      `json:"filter,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	Stats      pkg.CollectsStats `json:"stats"`
+}
+
+// Request is the summary of a recorded request
+type Request struct {
+	Key        string    `json:"key"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"statusCode"`
+	StartedAt  time.Time `json:"startedAt"`
+	// Duration is in milliseconds
+	Duration int64 `json:"duration"`
+}
+
+// Session records the requests which match its filter into a test suite
+type Session struct {
+	lock  sync.RWMutex
+	name  string
+	state State
+	// filterPath and rule are the filter which is given, the requests should match both of them
+	filterPath []string
+	rule       *filter.Rule
+	filter     filter.Filter
+	createdAt  time.Time
+	collects   *pkg.Collects
+	exporter   pkg.Exporter
+	requests   []Request
+}
+
+// Options creates the parts of the sessions, they are the same as the collector's by default
+type Options struct {
+	// Exporter creates the exporter of a session, it is a SampleExporter if it is nil
+	Exporter func() pkg.Exporter
+	// KeyStrategy creates the strategy to deduplicate the requests, it is a URLKeyStrategy if it is nil
+	KeyStrategy func() pkg.KeyStrategy
+}
+
+func newSession(name string, filterPath []string, rule *filter.Rule, options Options) (session *Session, err error) {
+	session = &Session{
+		name:      name,
+		state:     StateRecording,
+		createdAt: time.Now(),
+		collects:  pkg.NewCollects(),
+	}
+	if err = session.SetFilter(filterPath, rule); err != nil {
+		return
+	}
+	if options.Exporter != nil {
+		session.exporter = options.Exporter()
+	} else {
+		session.exporter = pkg.NewSampleExporter(false)
+	}
+	if options.KeyStrategy != nil {
+		session.collects.SetKeyStrategy(options.KeyStrategy())
+	}
+	session.collects.AddRawEvent(session.addRequest)
+	session.collects.AddEvent(session.exporter.Add)
+	return
+}
+
+// Name returns the name of the session
+func (s *Session) Name() string {
+	return s.name
+}
+
+// Info returns the summary of the session
+func (s *Session) Info() Info {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return Info{
+		Name:       s.name,
+		State:      s.state,
+		FilterPath: append([]string{}, s.filterPath...),
+		Filter:     s.rule,
+		CreatedAt:  s.createdAt,
+		Stats:      s.collects.Stats(),
+	}
+}
+
+// SetFilter sets the path prefixes and the filter rule of the requests to record, they are the same as the flags of
+// the collector. All the requests are recorded if both of them are empty.
+func (s *Session) SetFilter(filterPath []string, rule *filter.Rule) (err error) {
+	filters := filter.And{}
+	if len(filterPath) > 0 {
+		filters = append(filters, &filter.URLPathFilter{PathPrefix: append([]string{}, filterPath...)})
+	}
+	if rule != nil {
+		var ruleFilter filter.Filter
+		if ruleFilter, err = rule.Build(); err != nil {
+			return
+		}
+		filters = append(filters, ruleFilter)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.filterPath = append([]string{}, filterPath...)
+	s.rule, s.filter = rule, filters
+	return
+}
+
+// Pause stops recording until it is resumed
+func (s *Session) Pause() error {
+	return s.transit(StateRecording, StatePaused)
+}
+
+// Resume continues recording
+func (s *Session) Resume() error {
+	return s.transit(StatePaused, StateRecording)
+}
+
+func (s *Session) transit(from, to State) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.state != from {
+		return fmt.Errorf("%w: the session %q is %s", ErrConflict, s.name, s.state)
+	}
+	s.state = to
+	return nil
+}
+
+// Stop stops recording, the pending requests are written into the test suite
+func (s *Session) Stop() (err error) {
+	s.lock.Lock()
+	stopped := s.state == StateStopped
+	s.state = StateStopped
+	s.lock.Unlock()
+	if !stopped {
+		err = s.collects.StopWithTimeout(pkg.DefaultStopTimeout)
+	}
+	return
+}
+
+// Add records a request if the session is recording and the request matches the filter
+func (s *Session) Add(req *http.Request, resp *pkg.SimpleResponse) bool {
+	if !s.Match(newExchange(req, resp)) {
+		return false
+	}
+	s.collects.Add(req, resp)
+	return true
+}
+
+// Match returns true if the session is recording and the request matches the filter
+func (s *Session) Match(exchange *filter.Exchange) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.state == StateRecording && s.filter.Match(exchange)
+}
+
+func newExchange(req *http.Request, resp *pkg.SimpleResponse) (exchange *filter.Exchange) {
+	exchange = &filter.Exchange{Method: req.Method, URL: req.URL}
+	if resp != nil {
+		exchange.StatusCode = resp.StatusCode
+	}
+	return
+}
+
+func (s *Session) addRequest(reqAndResp *pkg.RequestAndResponse) {
+	request := Request{
+		Key:    reqAndResp.Key,
+		Method: reqAndResp.Request.Method,
+		URL:    reqAndResp.Request.URL.String(),
+	}
+	if resp := reqAndResp.Response; resp != nil {
+		request.StatusCode = resp.StatusCode
+		request.StartedAt = resp.StartedAt
+		request.Duration = resp.Duration.Milliseconds()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.requests = append(s.requests, request); len(s.requests) > MaxRequests {
+		s.requests = s.requests[len(s.requests)-MaxRequests:]
+	}
+}
+
+// Requests returns the recent recorded requests, including the duplicated ones
+func (s *Session) Requests() []Request {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return append([]Request{}, s.requests...)
+}
+
+// Export exports the test suite, it waits for the pending requests until the context is done
+func (s *Session) Export(ctx context.Context) (string, error) {
+	_ = s.collects.Wait(ctx)
+	return s.exporter.Export()
+}
+
+// Manager keeps the named sessions
+type Manager struct {
+	lock     sync.RWMutex
+	options  Options
+	sessions map[string]*Session
+}
+
+// NewManager creates a session manager
+func NewManager(options Options) *Manager {
+	return &Manager{
+		options:  options,
+		sessions: map[string]*Session{},
+	}
+}
+
+// Start starts a new session, the name must be unique
+func (m *Manager) Start(name string, filterPath []string, rule *filter.Rule) (session *Session, err error) {
+	if name == "" {
+		return nil, errors.New("the name of the session is required")
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.sessions[name]; ok {
+		return nil, fmt.Errorf("%w: the session %q exists", ErrConflict, name)
+	}
+	if session, err = newSession(name, filterPath, rule, m.options); err == nil {
+		m.sessions[name] = session
+	}
+	return
+}
+
+// Get returns a session by its name
+func (m *Manager) Get(name string) (*Session, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if session, ok := m.sessions[name]; ok {
+		return session, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+}
+
+// List returns all the sessions which are sorted by their names
+func (m *Manager) List() (sessions []*Session) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].name < sessions[j].name
+	})
+	return
+}
+
+// Delete stops and removes a session
+func (m *Manager) Delete(name string) (err error) {
+	m.lock.Lock()
+	session, ok := m.sessions[name]
+	delete(m.sessions, name)
+	m.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return session.Stop()
+}
+
+// Match returns true if any session records the request
+func (m *Manager) Match(exchange *filter.Exchange) bool {
+	for _, session := range m.List() {
+		if session.Match(exchange) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add gives a request to all the sessions, each of them receives its own copy of the request body
+func (m *Manager) Add(req *http.Request, resp *pkg.SimpleResponse) {
+	reqAndResp := &pkg.RequestAndResponse{Request: req}
+	body := reqAndResp.ReadRequestBody()
+	exchange := newExchange(req, resp)
+	for _, session := range m.List() {
+		if session.Match(exchange) {
+			clone := req.Clone(req.Context())
+			clone.Body = io.NopCloser(bytes.NewReader(body))
+			session.Add(clone, resp)
+		}
+	}
+}
+
+// Stop stops all the sessions
+func (m *Manager) Stop() (err error) {
+	for _, session := range m.List() {
+		if stopErr := session.Stop(); err == nil {
+			err = stopErr
+		}
+	}
+	return
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/filter"
+	"github.com/linuxsuren/atest-ext-collector/pkg/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func add(manager *session.Manager, method, api, body string) {
+	request, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+	manager.Add(request, &pkg.SimpleResponse{StatusCode: http.StatusOK})
+}
+
+func TestManager(t *testing.T) {
+	manager := session.NewManager(session.Options{})
+	defer func() {
+		assert.NoError(t, manager.Stop())
+	}()
+
+	users, err := manager.Start("users", []string{"/api/users"}, nil)
+	assert.NoError(t, err)
+	all, err := manager.Start("all", nil, nil)
+	assert.NoError(t, err)
+	_, err = manager.Start("users", nil, nil)
+	assert.ErrorIs(t, err, session.ErrConflict)
+	_, err = manager.Start("", nil, nil)
+	assert.Error(t, err)
+
+	request, _ := http.NewRequest(http.MethodGet, "http://foo/api/orders", nil)
+	assert.True(t, manager.Match(&filter.Exchange{Method: request.Method, URL: request.URL}))
+	assert.True(t, users.Match(&filter.Exchange{URL: request.URL.ResolveReference(&url.URL{Path: "/api/users/1"})}))
+
+	// both sessions receive the request body
+	add(manager, http.MethodPost, "http://foo/api/users", `{"name":"rick"}`)
+	add(manager, http.MethodGet, "http://foo/api/orders", "")
+	for _, s := range []*session.Session{users, all} {
+		suite, err := s.Export(context.Background())
+		assert.NoError(t, err)
+		assert.Contains(t, suite, `{"name":"rick"}`, s.Name())
+	}
+	assert.Len(t, users.Requests(), 1)
+	assert.Len(t, all.Requests(), 2)
+
+	// the paused session ignores the requests
+	assert.NoError(t, users.Pause())
+	assert.ErrorIs(t, users.Pause(), session.ErrConflict)
+	add(manager, http.MethodGet, "http://foo/api/users/2", "")
+	assert.Len(t, users.Requests(), 1)
+	assert.NoError(t, users.Resume())
+	assert.NoError(t, users.SetFilter([]string{"/api/orders"}, &filter.Rule{Exclude: []filter.Rule{{Method: []string{"DELETE"}}}}))
+	add(manager, http.MethodGet, "http://foo/api/orders/2", "")
+	add(manager, http.MethodDelete, "http://foo/api/orders/2", "")
+	assert.Len(t, users.Requests(), 2)
+	assert.Equal(t, []string{"/api/orders"}, users.Info().FilterPath)
+	assert.NotNil(t, users.Info().Filter)
+	assert.Error(t, users.SetFilter(nil, &filter.Rule{PathRegex: []string{"("}}))
+	assert.Equal(t, []string{"/api/orders"}, users.Info().FilterPath)
+	_, err = manager.Start("invalid", nil, &filter.Rule{Status: []string{"fake"}})
+	assert.Error(t, err)
+
+	// the stopped session keeps the test suite
+	assert.NoError(t, users.Stop())
+	assert.ErrorIs(t, users.Resume(), session.ErrConflict)
+	add(manager, http.MethodGet, "http://foo/api/orders/3", "")
+	assert.Len(t, users.Requests(), 2)
+	assert.Equal(t, session.StateStopped, users.Info().State)
+	assert.Equal(t, int64(2), users.Info().Stats.Unique)
+
+	sessions := manager.List()
+	if assert.Len(t, sessions, 2) {
+		assert.Equal(t, "all", sessions[0].Name())
+	}
+	assert.NoError(t, manager.Delete("users"))
+	assert.ErrorIs(t, manager.Delete("users"), session.ErrNotFound)
+	_, err = manager.Get("users")
+	assert.ErrorIs(t, err, session.ErrNotFound)
+}
+
+func TestManagerOptions(t *testing.T) {
+	manager := session.NewManager(session.Options{
+		Exporter: func() pkg.Exporter {
+			return pkg.NewHARExporter()
+		},
+		KeyStrategy: func() pkg.KeyStrategy {
+			return &pkg.NormalizedKeyStrategy{}
+		},
+	})
+	s, err := manager.Start("har", nil, nil)
+	assert.NoError(t, err)
+	add(manager, http.MethodGet, "http://foo/api/users/1", "")
+	add(manager, http.MethodGet, "http://foo/api/users/2", "")
+	assert.NoError(t, s.Stop())
+
+	assert.Equal(t, int64(1), s.Info().Stats.Unique)
+	har, err := s.Export(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, har, `"url": "http://foo/api/users/1"`)
+
+	// the request body could still be read after being recorded
+	request, _ := http.NewRequest(http.MethodPost, "http://foo/api/users", bytes.NewBufferString("body"))
+	manager.Add(request, nil)
+	data, _ := io.ReadAll(request.Body)
+	assert.Equal(t, "body", string(data))
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// atestSink creates or updates the test cases of a suite on an api-testing server through its HTTP API.
+// The target is the address of the server, the query parameters are the options, like:
+// http://localhost:8080?suite=sample&store=local&retry=3
+type atestSink struct {
+	lock          sync.Mutex
+	server        string
+	suite         string
+	store         string
+	retry         int
+	retryInterval time.Duration
+	client        *http.Client
+	config        Config
+	exporter      *pkg.SampleExporter
+	existing      map[string]bool
+	names         map[string]string
+	used          map[string]int
+}
+
+const (
+	atestDefaultSuite = "collected"
+	atestDefaultRetry = 3
+)
+
+func init() {
+	Registry("atest", func() Sink {
+		return &atestSink{
+			client:        &http.Client{Timeout: 10 * time.Second},
+			retryInterval: 200 * time.Millisecond,
+			existing:      map[string]bool{},
+			names:         map[string]string{},
+			used:          map[string]int{},
+		}
+	})
+}
+
+type atestPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type atestTestCase struct {
+	Name     string        `json:"name"`
+	Request  atestRequest  `json:"request"`
+	Response atestResponse `json:"response"`
+}
+
+type atestRequest struct {
+	API    string      `json:"api"`
+	Method string      `json:"method"`
+	Header []atestPair `json:"header"`
+	Query  []atestPair `json:"query"`
+	Form   []atestPair `json:"form"`
+	Body   string      `json:"body"`
+}
+
+type atestResponse struct {
+	StatusCode       int         `json:"statusCode"`
+	Body             string      `json:"body"`
+	Header           []atestPair `json:"header"`
+	BodyFieldsExpect []atestPair `json:"bodyFieldsExpect"`
+	Verify           []string    `json:"verify"`
+	Schema           string      `json:"schema"`
+}
+
+type atestReply struct {
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+func (s *atestSink) Init(config Config) (err error) {
+	var target *url.URL
+	if target, err = url.Parse(config.Target); err != nil {
+		return
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return errors.New("the address of the api-testing server is required, like http://localhost:8080?suite=sample")
+	}
+
+	query := target.Query()
+	s.suite, s.store, s.retry = query.Get("suite"), query.Get("store"), atestDefaultRetry
+	if s.suite == "" {
+		s.suite = atestDefaultSuite
+	}
+	if retry := query.Get("retry"); retry != "" {
+		if s.retry, err = strconv.Atoi(retry); err != nil {
+			return fmt.Errorf("invalid retry %q: %v", retry, err)
+		}
+	}
+	target.RawQuery, target.Fragment = "", ""
+	s.server = target.String()
+	s.config = config
+	var ok bool
+	if s.exporter, ok = config.newExporter().(*pkg.SampleExporter); !ok {
+		return errors.New("only the test suite exporter is supported")
+	}
+
+	// the existing test cases are updated instead of being created again
+	suites := struct {
+		Data map[string]struct {
+			Data []string `json:"data"`
+		} `json:"data"`
+	}{}
+	if err = s.call("GetSuites", struct{}{}, &suites); err != nil {
+		return
+	}
+	if items, ok := suites.Data[s.suite]; ok {
+		for _, name := range items.Data {
+			s.existing[name] = true
+		}
+	} else {
+		err = s.call("CreateTestSuite", map[string]string{"name": s.suite}, nil)
+	}
+	return
+}
+
+func (s *atestSink) Handle(reqAndResp *pkg.RequestAndResponse) (err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// the exporter is shared by all the requests, then the later ones could reference the earlier ones
+	s.exporter.Add(reqAndResp)
+	latest, ok := s.exporter.Latest()
+	if !ok {
+		return
+	}
+
+	testCase := toAtestTestCase(latest)
+	testCase.Name = s.caseName(reqAndResp.Key, testCase.Name)
+
+	method := "CreateTestCase"
+	if s.existing[testCase.Name] {
+		method = "UpdateTestCase"
+	}
+	if err = s.call(method, map[string]interface{}{"suiteName": s.suite, "data": testCase}, nil); err == nil {
+		s.existing[testCase.Name] = true
+	}
+	return
+}
+
+// caseName returns the same name for the same key, the different keys never share a name
+func (s *atestSink) caseName(key, name string) string {
+	if assigned, ok := s.names[key]; ok {
+		return assigned
+	}
+
+	assigned := name
+	if count, ok := s.used[name]; ok {
+		assigned = fmt.Sprintf("%s-%d", name, count)
+	}
+	s.used[name]++
+	s.names[key] = assigned
+	return assigned
+}
+
+// call sends a request to the HTTP gateway of the api-testing server, the failures are retried
+func (s *atestSink) call(method string, payload, result interface{}) (err error) {
+	var data []byte
+	if data, err = json.Marshal(payload); err != nil {
+		return
+	}
+
+	interval := s.retryInterval
+	for i := 0; ; i++ {
+		var retryable bool
+		if retryable, err = s.send(method, data, result); err == nil || !retryable || i >= s.retry {
+			break
+		}
+		time.Sleep(interval)
+		interval *= 2
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to call %s: %v", method, err)
+	}
+	return
+}
+
+func (s *atestSink) send(method string, data []byte, result interface{}) (retryable bool, err error) {
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, s.server+"/server.Runner/"+method, bytes.NewReader(data)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.store != "" {
+		req.Header.Set("X-Store-Name", s.store)
+	}
+
+	var resp *http.Response
+	if resp, err = s.client.Do(req); err != nil {
+		retryable = true
+		return
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		retryable = true
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		retryable = resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return
+	}
+
+	reply := atestReply{}
+	if err = json.Unmarshal(body, &reply); err == nil && reply.Error != "" {
+		err = errors.New(reply.Error)
+	} else if err == nil && result != nil {
+		err = json.Unmarshal(body, result)
+	}
+	return
+}
+
+func (s *atestSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func toAtestTestCase(testCase testing.TestCase) atestTestCase {
+	return atestTestCase{
+		Name: testCase.Name,
+		Request: atestRequest{
+			API:    testCase.Request.API,
+			Method: testCase.Request.Method,
+			Header: toAtestPairs(testCase.Request.Header),
+			Query:  toAtestPairs(testCase.Request.Query),
+			Form:   toAtestPairs(testCase.Request.Form),
+			Body:   testCase.Request.Body,
+		},
+		Response: atestResponse{
+			StatusCode:       testCase.Expect.StatusCode,
+			Body:             testCase.Expect.Body,
+			Header:           toAtestPairs(testCase.Expect.Header),
+			BodyFieldsExpect: toAtestPairs(testCase.Expect.BodyFieldsExpect),
+			Verify:           testCase.Expect.Verify,
+			Schema:           testCase.Expect.Schema,
+		},
+	}
+}
+
+func toAtestPairs[V any](data map[string]V) (pairs []atestPair) {
+	pairs = []atestPair{}
+	for key, value := range data {
+		pairs = append(pairs, atestPair{Key: key, Value: fmt.Sprintf("%v", value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+	return
+}
+
+// Remote marks the requests could be dropped when the server is slow
+func (s *atestSink) Remote() {}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// dirSink writes each request into its own directory, which has a test suite of the single test case
+// and the HAR entry of the request
+type dirSink struct {
+	lock     sync.Mutex
+	target   string
+	config   Config
+	exporter *pkg.SampleExporter
+	names    map[string]int
+}
+
+var nonNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+func init() {
+	Registry("dir", func() Sink {
+		return &dirSink{names: map[string]int{}}
+	})
+}
+
+func (s *dirSink) Init(config Config) (err error) {
+	if config.Target == "" {
+		return errors.New("the target directory is required")
+	}
+	var ok bool
+	if s.exporter, ok = config.newExporter().(*pkg.SampleExporter); !ok {
+		return errors.New("only the test suite exporter is supported")
+	}
+	s.target = config.Target
+	s.config = config
+	return os.MkdirAll(s.target, 0755)
+}
+
+func (s *dirSink) Handle(reqAndResp *pkg.RequestAndResponse) (err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// the exporter is shared by all the requests, then the later ones could reference the earlier ones
+	s.exporter.Add(reqAndResp)
+	latest, ok := s.exporter.Latest()
+	if !ok {
+		return
+	}
+
+	var suite string
+	if suite, err = s.exporter.ExportLatest(); err != nil {
+		return
+	}
+	var entry []byte
+	if entry, err = json.MarshalIndent(s.config.newHAREntry(reqAndResp), "", "  "); err != nil {
+		return
+	}
+
+	dir := filepath.Join(s.target, s.caseName(latest.Name))
+	if err = os.MkdirAll(dir, 0755); err == nil {
+		if err = pkg.WriteFileAtomic(filepath.Join(dir, "suite.yaml"), []byte(suite), 0644); err == nil {
+			err = pkg.WriteFileAtomic(filepath.Join(dir, "entry.json"), entry, 0644)
+		}
+	}
+	return
+}
+
+// caseName returns a unique directory name of the test case
+func (s *dirSink) caseName(name string) string {
+	if name = strings.Trim(nonNameChars.ReplaceAllString(name, "-"), "-."); name == "" {
+		name = "case"
+	}
+
+	count, ok := s.names[name]
+	s.names[name] = count + 1
+	if ok {
+		name = fmt.Sprintf("%s-%d", name, count)
+	}
+	return name
+}
+
+func (s *dirSink) Close() error {
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// fileSink writes all the requests into a test suite file, or the other format of the exporter.
+// It is rewritten atomically after each request, or at most once in the flush interval.
+type fileSink struct {
+	lock     sync.Mutex
+	raw      bool
+	exporter pkg.Exporter
+	writer   *pkg.OutputWriter
+}
+
+func init() {
+	Registry("file", func() Sink {
+		return &fileSink{}
+	})
+}
+
+func (s *fileSink) Init(config Config) (err error) {
+	if config.Target == "" {
+		return errors.New("the target file is required")
+	}
+	s.raw = config.Raw
+	s.exporter = config.newExporter()
+	s.writer = pkg.NewOutputWriter(config.FlushInterval)
+	s.writer.AddOutput(config.Target, s.exporter)
+	return s.writer.Flush()
+}
+
+func (s *fileSink) Handle(reqAndResp *pkg.RequestAndResponse) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.raw {
+		s.exporter.Add(reqAndResp)
+	}
+	return s.writer.Write()
+}
+
+// HandleRaw adds the duplicated requests into the raw exporter, the file is marked as changed for the other
+// exporters as well because their outputs may depend on the duplicated requests, like the inferred schemas
+func (s *fileSink) HandleRaw(reqAndResp *pkg.RequestAndResponse) error {
+	if s.raw {
+		s.exporter.Add(reqAndResp)
+	}
+	s.writer.Touch(reqAndResp)
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.writer.Flush()
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// DefaultQueueSize is the default size of the queue of each sink
+const DefaultQueueSize = 1024
+
+// Remote is implemented by the sinks of the remote services. Their requests are dropped when the queues are full,
+// then an unavailable service does not block the collector, the other sinks block until there is room.
+type Remote interface {
+	Remote()
+}
+
+// RawSink is implemented by the sinks which take the duplicated requests as well, like the OpenAPI file
+// which merges all the examples. HandleRaw is called in the goroutines of the requests.
+type RawSink interface {
+	HandleRaw(reqAndResp *pkg.RequestAndResponse) error
+}
+
+// Queue delivers the requests to a sink in its own goroutine, then a slow sink does not block the collector
+// or the other sinks until its queue is full
+type Queue struct {
+	name    string
+	sink    Sink
+	queue   chan *pkg.RequestAndResponse
+	done    chan struct{}
+	once    sync.Once
+	dropped int64
+}
+
+// NewQueue starts the goroutine of a sink, the queue should be closed instead of the sink
+func NewQueue(name string, sink Sink, size int) (queue *Queue) {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	queue = &Queue{
+		name:  name,
+		sink:  sink,
+		queue: make(chan *pkg.RequestAndResponse, size),
+		done:  make(chan struct{}),
+	}
+	go queue.run()
+	return
+}
+
+// Handle is the EventHandle of the queue, the request is copied because the sinks run in their own goroutines
+func (q *Queue) Handle(reqAndResp *pkg.RequestAndResponse) {
+	item := reqAndResp.Clone()
+	if _, remote := q.sink.(Remote); !remote {
+		q.queue <- item
+		return
+	}
+
+	select {
+	case q.queue <- item:
+	default:
+		atomic.AddInt64(&q.dropped, 1)
+		log.Printf("the queue of the sink %s is full, dropped %s %s", q.name, item.Request.Method, item.Request.URL)
+	}
+}
+
+// HandleRaw is the raw EventHandle of the queue, it is called only if the sink is a RawSink
+func (q *Queue) HandleRaw(reqAndResp *pkg.RequestAndResponse) {
+	if raw, ok := q.sink.(RawSink); ok {
+		if err := raw.HandleRaw(reqAndResp); err != nil {
+			q.logError(reqAndResp, err)
+		}
+	}
+}
+
+// Dropped returns the count of the dropped requests
+func (q *Queue) Dropped() int64 {
+	return atomic.LoadInt64(&q.dropped)
+}
+
+// Close waits until all the queued requests are handled, then closes the sink.
+// The queue should be closed after stopping the collector.
+func (q *Queue) Close() error {
+	q.once.Do(func() {
+		close(q.queue)
+	})
+	<-q.done
+	return q.sink.Close()
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	for item := range q.queue {
+		if err := q.sink.Handle(item); err != nil {
+			q.logError(item, err)
+		}
+	}
+}
+
+func (q *Queue) logError(reqAndResp *pkg.RequestAndResponse, err error) {
+	log.Printf("failed to handle %s %s by the sink %s: %v", reqAndResp.Request.Method,
+		reqAndResp.Request.URL, q.name, err)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sink delivers the collected requests to the destinations, like files, streams and services.
+package sink
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// Sink receives the collected requests
+type Sink interface {
+	// Init prepares the sink before receiving any request
+	Init(config Config) error
+	// Handle receives a collected request
+	Handle(reqAndResp *pkg.RequestAndResponse) error
+	// Close flushes the pending data and releases the resources
+	Close() error
+}
+
+// Config is the config of a sink
+type Config struct {
+	// Target is the file, directory or URL of the sink, it depends on the sink
+	Target string
+	// Exporter creates the exporter of the test cases, it is a SampleExporter if it is nil
+	Exporter func() pkg.Exporter
+	// HeaderPolicy drops and masks the request headers of the HAR entries, it is the default policy if it is nil
+	HeaderPolicy *pkg.HeaderPolicy
+	// Raw means the exporter takes the duplicated requests as well, like the OpenAPI one
+	Raw bool
+	// FlushInterval is the min interval of rewriting the files, they are rewritten after each request if it is zero
+	FlushInterval time.Duration
+}
+
+func (c Config) newExporter() pkg.Exporter {
+	if c.Exporter == nil {
+		return pkg.NewSampleExporter(false)
+	}
+	return c.Exporter()
+}
+
+// newHAREntry creates the HAR entry of a request, the request headers go through the header policy,
+// then the secrets are not delivered to the destinations
+func (c Config) newHAREntry(reqAndResp *pkg.RequestAndResponse) (entry pkg.HAREntry) {
+	policy := c.HeaderPolicy
+	if policy == nil {
+		policy = pkg.NewHeaderPolicy()
+	}
+	entry = pkg.NewHAREntry(reqAndResp)
+	header := policy.Apply(reqAndResp.Request.Header)
+	entry.Request.Headers = make([]pkg.HARNameValue, 0, len(header))
+	for _, name := range pkg.SortedKeys(header) {
+		entry.Request.Headers = append(entry.Request.Headers, pkg.HARNameValue{Name: name, Value: header[name]})
+	}
+	return
+}
+
+// Factory creates a sink
+type Factory func() Sink
+
+var allSinks = map[string]Factory{}
+
+// Registry registers a sink factory with its name
+func Registry(name string, factory Factory) {
+	allSinks[name] = factory
+}
+
+// GetSink creates a sink by its name, it returns nil if the name is unknown
+func GetSink(name string) Sink {
+	if factory, ok := allSinks[name]; ok {
+		return factory()
+	}
+	return nil
+}
+
+// GetSinkNames returns the sorted names of all the sinks
+func GetSinkNames() (names []string) {
+	for name := range allSinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Spec is a sink with its target, it is written as name:target, like file:sample.yaml
+type Spec struct {
+	Name   string
+	Target string
+}
+
+// ParseSpec parses the sink spec, the name must be registered
+func ParseSpec(text string) (spec Spec, err error) {
+	pair := strings.SplitN(text, ":", 2)
+	spec.Name = strings.TrimSpace(pair[0])
+	if len(pair) == 2 {
+		spec.Target = strings.TrimSpace(pair[1])
+	}
+	if _, ok := allSinks[spec.Name]; !ok {
+		err = fmt.Errorf("unknown sink %q, supported: %v", spec.Name, GetSinkNames())
+	}
+	return
+}
+
+// Open creates and initializes a sink by the spec, the target of the config is taken from the spec
+func Open(spec Spec, config Config) (sink Sink, err error) {
+	if sink = GetSink(spec.Name); sink == nil {
+		err = fmt.Errorf("unknown sink %q", spec.Name)
+		return
+	}
+	config.Target = spec.Target
+	if err = sink.Init(config); err != nil {
+		err = fmt.Errorf("failed to init the sink %q: %v", spec.Name, err)
+	}
+	return
+}
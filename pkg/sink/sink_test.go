@@ -0,0 +1,412 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/sink"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReqAndResp(method, api, body string) *pkg.RequestAndResponse {
+	request, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("Connection", "keep-alive")
+	return &pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       `{"name":"rick"}`,
+	}}
+}
+
+func TestParseSpec(t *testing.T) {
+	assert.Equal(t, []string{"atest", "dir", "file", "stdout", "webhook"}, sink.GetSinkNames())
+
+	spec, err := sink.ParseSpec("webhook:http://localhost:8080/hook")
+	assert.NoError(t, err)
+	assert.Equal(t, sink.Spec{Name: "webhook", Target: "http://localhost:8080/hook"}, spec)
+
+	spec, err = sink.ParseSpec("stdout")
+	assert.NoError(t, err)
+	assert.Equal(t, sink.Spec{Name: "stdout"}, spec)
+
+	_, err = sink.ParseSpec("fake:target")
+	assert.Error(t, err)
+	assert.Nil(t, sink.GetSink("fake"))
+
+	_, err = sink.Open(sink.Spec{Name: "file"}, sink.Config{})
+	assert.Error(t, err)
+	_, err = sink.Open(sink.Spec{Name: "webhook", Target: "localhost"}, sink.Config{})
+	assert.Error(t, err)
+	_, err = sink.Open(sink.Spec{Name: "dir"}, sink.Config{})
+	assert.Error(t, err)
+}
+
+func TestFileSink(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "sample.yaml")
+	s, err := sink.Open(sink.Spec{Name: "file", Target: target}, sink.Config{})
+	assert.NoError(t, err)
+	assert.FileExists(t, target)
+
+	assert.NoError(t, s.Handle(newReqAndResp(http.MethodGet, "http://foo/api/users", "")))
+	assert.NoError(t, s.Close())
+	data, err := os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "api: http://foo/api/users")
+}
+
+func TestStdoutSink(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	assert.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = writer
+	s, err := sink.Open(sink.Spec{Name: "stdout"}, sink.Config{})
+	os.Stdout = stdout
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Handle(newReqAndResp(http.MethodPost, "http://foo/api/users", `{"name":"rick"}`)))
+	assert.NoError(t, s.Handle(newReqAndResp(http.MethodGet, "http://foo/api/users", "")))
+	assert.NoError(t, s.Close())
+	assert.NoError(t, writer.Close())
+
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	assert.Len(t, lines, 2)
+	entry := pkg.HAREntry{}
+	assert.NoError(t, json.Unmarshal(lines[0], &entry))
+	assert.Equal(t, http.MethodPost, entry.Request.Method)
+	assert.Equal(t, `{"name":"rick"}`, entry.Request.PostData.Text)
+	// the secrets are not written in plaintext, and the hop-by-hop headers are dropped
+	assert.Equal(t, []pkg.HARNameValue{{Name: "Authorization", Value: `{{env "AUTHORIZATION"}}`}}, entry.Request.Headers)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var lock sync.Mutex
+	var received []pkg.HAREntry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := pkg.HAREntry{}
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil || r.URL.Path != "/hook" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lock.Lock()
+		received = append(received, entry)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	s, err := sink.Open(sink.Spec{Name: "webhook", Target: server.URL + "/hook"}, sink.Config{
+		HeaderPolicy: &pkg.HeaderPolicy{Mask: []string{"Authorization"}, Mode: pkg.SecretModeMask},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Handle(newReqAndResp(http.MethodGet, "http://foo/api/users", "")))
+	assert.NoError(t, s.Close())
+	assert.Len(t, received, 1)
+	assert.Equal(t, "http://foo/api/users", received[0].Request.URL)
+	assert.NotContains(t, received[0].Request.Headers, pkg.HARNameValue{Name: "Authorization", Value: "Bearer secret"})
+
+	s, err = sink.Open(sink.Spec{Name: "webhook", Target: server.URL + "/fake"}, sink.Config{})
+	assert.NoError(t, err)
+	assert.Error(t, s.Handle(newReqAndResp(http.MethodGet, "http://foo/api/users", "")))
+}
+
+func TestDirSink(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "cases")
+	s, err := sink.Open(sink.Spec{Name: "dir", Target: target}, sink.Config{Exporter: func() pkg.Exporter {
+		return pkg.NewSampleExporter(true)
+	}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Handle(newReqAndResp(http.MethodGet, "http://foo/api/users", "")))
+	assert.NoError(t, s.Handle(newReqAndResp(http.MethodGet, "http://foo/api/users?page=2", "")))
+	assert.NoError(t, s.Close())
+
+	entries, err := os.ReadDir(target)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "users", entries[0].Name())
+		assert.Equal(t, "users-1", entries[1].Name())
+	}
+	data, err := os.ReadFile(filepath.Join(target, "users", "suite.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `{"name":"rick"}`)
+	data, err = os.ReadFile(filepath.Join(target, "users-1", "entry.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "Bearer secret")
+}
+
+func TestDirSinkWithChain(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "cases")
+	s, err := sink.Open(sink.Spec{Name: "dir", Target: target}, sink.Config{Exporter: func() pkg.Exporter {
+		return pkg.NewSampleExporter(true).WithChain(true)
+	}})
+	assert.NoError(t, err)
+
+	login := newReqAndResp(http.MethodPost, "http://foo/api/login", "")
+	login.Response.Body = `{"token":"eyJhbGciOiJIUzI1NiJ9.chained"}`
+	users := newReqAndResp(http.MethodGet, "http://foo/api/users", "")
+	users.Request.Header.Set("X-Token", "eyJhbGciOiJIUzI1NiJ9.chained")
+	assert.NoError(t, s.Handle(login))
+	assert.NoError(t, s.Handle(users))
+	assert.NoError(t, s.Close())
+
+	// the newest test case references the earlier one
+	data, err := os.ReadFile(filepath.Join(target, "users", "suite.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "X-Token: '{{.login.token}}'")
+	assert.NotContains(t, string(data), "name: login")
+}
+
+// fakeAtestServer is a stand-in of the HTTP gateway of the api-testing server
+type fakeAtestServer struct {
+	lock     sync.Mutex
+	suites   map[string]map[string]map[string]interface{}
+	calls    []string
+	failures int
+}
+
+func (s *fakeAtestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = append(s.calls, r.URL.Path)
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	payload := map[string]interface{}{}
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+	var reply interface{} = map[string]string{}
+	switch r.URL.Path {
+	case "/server.Runner/GetSuites":
+		data := map[string]interface{}{}
+		for name, items := range s.suites {
+			names := []string{}
+			for item := range items {
+				names = append(names, item)
+			}
+			data[name] = map[string]interface{}{"data": names}
+		}
+		reply = map[string]interface{}{"data": data}
+	case "/server.Runner/CreateTestSuite":
+		s.suites[payload["name"].(string)] = map[string]map[string]interface{}{}
+	case "/server.Runner/CreateTestCase", "/server.Runner/UpdateTestCase":
+		items, ok := s.suites[payload["suiteName"].(string)]
+		testCase := payload["data"].(map[string]interface{})
+		name := testCase["name"].(string)
+		_, exists := items[name]
+		if !ok || exists != strings.HasSuffix(r.URL.Path, "UpdateTestCase") {
+			reply = map[string]string{"error": "invalid test case " + name}
+		} else {
+			items[name] = testCase
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+func TestAtestSink(t *testing.T) {
+	fake := &fakeAtestServer{suites: map[string]map[string]map[string]interface{}{
+		"existing": {"users": {}},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	exporter := func() pkg.Exporter {
+		return pkg.NewSampleExporter(true)
+	}
+	users := newReqAndResp(http.MethodGet, "http://foo/api/users", "")
+	users.Key = "GET-http://foo/api/users"
+	secondPage := newReqAndResp(http.MethodGet, "http://foo/api/users?page=2", "")
+	secondPage.Key = "GET-http://foo/api/users?page=2"
+
+	t.Run("create the suite", func(t *testing.T) {
+		// the unavailable server is retried
+		fake.failures = 1
+		s, err := sink.Open(sink.Spec{Name: "atest", Target: server.URL + "?suite=new&store=local"}, sink.Config{Exporter: exporter})
+		assert.NoError(t, err)
+		assert.Contains(t, fake.suites, "new")
+
+		assert.NoError(t, s.Handle(users))
+		assert.NoError(t, s.Handle(secondPage))
+		// the same key updates the same test case
+		assert.NoError(t, s.Handle(users))
+		assert.NoError(t, s.Close())
+
+		assert.Len(t, fake.suites["new"], 2)
+		assert.Contains(t, fake.suites["new"], "users-1")
+		request := fake.suites["new"]["users"]["request"].(map[string]interface{})
+		assert.Equal(t, "http://foo/api/users", request["api"])
+		assert.Equal(t, []string{"/server.Runner/GetSuites", "/server.Runner/GetSuites",
+			"/server.Runner/CreateTestSuite", "/server.Runner/CreateTestCase",
+			"/server.Runner/CreateTestCase", "/server.Runner/UpdateTestCase"}, fake.calls)
+	})
+
+	t.Run("update the existing test cases", func(t *testing.T) {
+		s, err := sink.Open(sink.Spec{Name: "atest", Target: server.URL + "?suite=existing"}, sink.Config{Exporter: exporter})
+		assert.NoError(t, err)
+		assert.NoError(t, s.Handle(users))
+		response := fake.suites["existing"]["users"]["response"].(map[string]interface{})
+		assert.Equal(t, float64(http.StatusOK), response["statusCode"])
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		s, err := sink.Open(sink.Spec{Name: "atest", Target: server.URL + "?suite=chain"}, sink.Config{
+			Exporter: func() pkg.Exporter {
+				return pkg.NewSampleExporter(true).WithChain(true)
+			},
+		})
+		assert.NoError(t, err)
+		login := newReqAndResp(http.MethodPost, "http://foo/api/login", "")
+		login.Response.Body = `{"token":"eyJhbGciOiJIUzI1NiJ9.chained"}`
+		login.Key = "POST-http://foo/api/login"
+		assert.NoError(t, s.Handle(login))
+		users := newReqAndResp(http.MethodGet, "http://foo/api/users", "")
+		users.Request.Header.Set("X-Token", "eyJhbGciOiJIUzI1NiJ9.chained")
+		users.Key = "GET-http://foo/api/users"
+		assert.NoError(t, s.Handle(users))
+
+		request := fake.suites["chain"]["users"]["request"].(map[string]interface{})
+		assert.Contains(t, request["header"], map[string]interface{}{"key": "X-Token", "value": "{{.login.token}}"})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		fake.failures = 10
+		_, err := sink.Open(sink.Spec{Name: "atest", Target: server.URL + "?retry=1"}, sink.Config{Exporter: exporter})
+		assert.Error(t, err)
+		assert.Equal(t, 8, fake.failures)
+
+		_, err = sink.Open(sink.Spec{Name: "atest", Target: server.URL + "?retry=fake"}, sink.Config{Exporter: exporter})
+		assert.Error(t, err)
+		_, err = sink.Open(sink.Spec{Name: "atest", Target: "localhost:8080"}, sink.Config{Exporter: exporter})
+		assert.Error(t, err)
+		fake.failures = 0
+
+		// the server replies the errors without retrying
+		fake.suites["conflict"] = map[string]map[string]interface{}{}
+		s, err := sink.Open(sink.Spec{Name: "atest", Target: server.URL + "?suite=conflict"}, sink.Config{Exporter: exporter})
+		assert.NoError(t, err)
+		fake.suites["conflict"]["users"] = map[string]interface{}{}
+		assert.Error(t, s.Handle(users))
+	})
+}
+
+// slowSink blocks until it is released
+type slowSink struct {
+	release chan struct{}
+	handled chan string
+	closed  bool
+}
+
+func (s *slowSink) Init(sink.Config) error {
+	return nil
+}
+
+func (s *slowSink) Handle(reqAndResp *pkg.RequestAndResponse) error {
+	<-s.release
+	s.handled <- string(reqAndResp.ReadRequestBody())
+	return nil
+}
+
+func (s *slowSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+// slowRemoteSink is a slow remote sink, its requests could be dropped
+type slowRemoteSink struct {
+	slowSink
+}
+
+func (s *slowRemoteSink) Remote() {}
+
+func TestQueue(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		slow := &slowSink{release: make(chan struct{}), handled: make(chan string, 10)}
+		queue := sink.NewQueue("slow", slow, 2)
+		// the caller is not blocked by the slow sink
+		for _, body := range []string{"a", "b"} {
+			queue.Handle(newReqAndResp(http.MethodPost, "http://foo/api/users", body))
+		}
+
+		close(slow.release)
+		assert.NoError(t, queue.Close())
+		assert.True(t, slow.closed)
+		assert.Equal(t, "a", <-slow.handled)
+		assert.Equal(t, "b", <-slow.handled)
+		assert.Zero(t, queue.Dropped())
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		slow := &slowRemoteSink{slowSink{release: make(chan struct{}), handled: make(chan string, 10)}}
+		queue := sink.NewQueue("slow", slow, 1)
+		queue.Handle(newReqAndResp(http.MethodPost, "http://foo/api/users", "a"))
+		assert.Eventually(t, func() bool {
+			// the first one is being handled, the second one is queued, then the others are dropped
+			queue.Handle(newReqAndResp(http.MethodPost, "http://foo/api/users", "b"))
+			return queue.Dropped() > 0
+		}, time.Second, time.Millisecond)
+
+		close(slow.release)
+		assert.NoError(t, queue.Close())
+		assert.Equal(t, "a", <-slow.handled)
+	})
+}
+
+func TestFileSinkRaw(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "openapi.yaml")
+	s, err := sink.Open(sink.Spec{Name: "file", Target: target}, sink.Config{
+		Exporter: func() pkg.Exporter {
+			return pkg.NewOpenAPIExporter("sample")
+		},
+		Raw:           true,
+		FlushInterval: time.Hour,
+	})
+	assert.NoError(t, err)
+
+	raw, ok := s.(sink.RawSink)
+	assert.True(t, ok)
+	users := newReqAndResp(http.MethodGet, "http://foo/api/users", "")
+	assert.NoError(t, raw.HandleRaw(users))
+	assert.NoError(t, raw.HandleRaw(newReqAndResp(http.MethodGet, "http://foo/api/users?page=2", "")))
+	// the raw requests are added already, and the file is not rewritten within the interval
+	assert.NoError(t, s.Handle(users))
+	data, err := os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "/api/users")
+
+	assert.NoError(t, s.Close())
+	data, err = os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "/api/users")
+}
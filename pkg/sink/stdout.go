@@ -0,0 +1,58 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// streamSink writes each request as a HAR entry per line, it is the same format as the journal
+type streamSink struct {
+	lock   sync.Mutex
+	writer io.Writer
+	config Config
+}
+
+func init() {
+	Registry("stdout", func() Sink {
+		return &streamSink{writer: os.Stdout}
+	})
+}
+
+func (s *streamSink) Init(config Config) error {
+	s.config = config
+	return nil
+}
+
+func (s *streamSink) Handle(reqAndResp *pkg.RequestAndResponse) (err error) {
+	var data []byte
+	if data, err = json.Marshal(s.config.newHAREntry(reqAndResp)); err == nil {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		_, err = s.writer.Write(append(data, '\n'))
+	}
+	return
+}
+
+func (s *streamSink) Close() error {
+	return nil
+}
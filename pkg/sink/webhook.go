@@ -0,0 +1,78 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// webhookSink posts each request as a HAR entry in JSON to the target URL
+type webhookSink struct {
+	target string
+	config Config
+	client *http.Client
+}
+
+func init() {
+	Registry("webhook", func() Sink {
+		return &webhookSink{client: &http.Client{Timeout: 10 * time.Second}}
+	})
+}
+
+func (s *webhookSink) Init(config Config) (err error) {
+	var target *url.URL
+	if target, err = url.Parse(config.Target); err == nil && (target.Scheme == "" || target.Host == "") {
+		err = errors.New("the target URL is required, like http://localhost:8080/webhook")
+	}
+	s.target, s.config = config.Target, config
+	return
+}
+
+func (s *webhookSink) Handle(reqAndResp *pkg.RequestAndResponse) (err error) {
+	var data []byte
+	if data, err = json.Marshal(s.config.newHAREntry(reqAndResp)); err != nil {
+		return
+	}
+
+	var resp *http.Response
+	if resp, err = s.client.Post(s.target, "application/json", bytes.NewReader(data)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return
+}
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// Remote marks the requests could be dropped when the service is slow
+func (s *webhookSink) Remote() {}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// spillQueue is a FIFO queue on the disk, the requests are kept as HAR entries per line like the journal.
+// The file is truncated once all the requests are read.
+type spillQueue struct {
+	lock        sync.Mutex
+	file        *os.File
+	readOffset  int64
+	writeOffset int64
+	count       int
+}
+
+func newSpillQueue() (queue *spillQueue, err error) {
+	var file *os.File
+	if file, err = os.CreateTemp("", "atest-collector-*.spill"); err == nil {
+		queue = &spillQueue{file: file}
+	}
+	return
+}
+
+// Push appends a request to the end of the queue
+func (q *spillQueue) Push(reqAndResp *RequestAndResponse) (err error) {
+	var data []byte
+	if data, err = json.Marshal(NewHAREntry(reqAndResp)); err != nil {
+		return
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var n int
+	n, err = q.file.WriteAt(append(data, '\n'), q.writeOffset)
+	q.writeOffset += int64(n)
+	if err == nil {
+		q.count++
+	}
+	return
+}
+
+// Pop takes the first request of the queue, it returns nil if the queue is empty.
+// The number of the requests which are given up is returned together with the error.
+func (q *spillQueue) Pop() (reqAndResp *RequestAndResponse, dropped int, err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.count == 0 {
+		return
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(q.file, q.readOffset, q.writeOffset-q.readOffset))
+	var line []byte
+	if line, err = reader.ReadBytes('\n'); err != nil {
+		// the rest of the file could not be read, give up all of them
+		dropped = q.count
+		q.reset()
+		return
+	}
+	q.readOffset += int64(len(line))
+	if q.count--; q.count == 0 {
+		q.reset()
+	}
+
+	entry := HAREntry{}
+	if err = json.Unmarshal(line, &entry); err == nil {
+		reqAndResp, err = entry.ToRequestAndResponse()
+	}
+	if err != nil {
+		reqAndResp, dropped = nil, 1
+	}
+	return
+}
+
+func (q *spillQueue) reset() {
+	q.readOffset, q.writeOffset, q.count = 0, 0, 0
+	_ = q.file.Truncate(0)
+}
+
+// Len returns the number of requests in the queue
+func (q *spillQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.count
+}
+
+// Close closes and removes the file
+func (q *spillQueue) Close() (err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err = q.file.Close(); err == nil {
+		err = os.Remove(q.file.Name())
+	}
+	return
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxBodySize is the default max size of a recorded body
+const DefaultMaxBodySize = 10 << 20
+
+// CaptureDone receives the recorded body, it is truncated if it is larger than
+// the limit, or the body is closed before reading all of it
+type CaptureDone func(data []byte, truncated bool)
+
+// capturedBody forwards the bytes as soon as they are read, and records them up to the limit
+type capturedBody struct {
+	io.ReadCloser
+	limit     int64
+	buf       bytes.Buffer
+	truncated bool
+	once      sync.Once
+	done      CaptureDone
+}
+
+// CaptureBody wraps the body to record it while it is being read, the callback is
+// called once the body is read to the end or closed. There is no limit if it is not positive.
+func CaptureBody(body io.ReadCloser, limit int64, done CaptureDone) io.ReadCloser {
+	return &capturedBody{ReadCloser: body, limit: limit, done: done}
+}
+
+func (b *capturedBody) Read(data []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(data)
+	if n > 0 {
+		chunk := data[:n]
+		if room := b.limit - int64(b.buf.Len()); b.limit > 0 && room < int64(n) {
+			chunk = chunk[:room]
+			b.truncated = true
+		}
+		b.buf.Write(chunk)
+	}
+	if err == io.EOF {
+		b.finish(false)
+	}
+	return
+}
+
+// Close closes the body, it is truncated if it has not been read to the end
+func (b *capturedBody) Close() error {
+	b.finish(true)
+	return b.ReadCloser.Close()
+}
+
+func (b *capturedBody) finish(interrupted bool) {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes(), b.truncated || interrupted)
+	})
+}
+
+// ServerSentEvent is an event of the text/event-stream
+type ServerSentEvent struct {
+	ID    string `json:"id,omitempty"`
+	Event string `json:"event,omitempty"`
+	Data  string `json:"data"`
+	Retry int    `json:"retry,omitempty"`
+}
+
+// IsEventStream returns true if the content type is text/event-stream
+func IsEventStream(contentType string) bool {
+	return getMediaType(contentType) == "text/event-stream"
+}
+
+// ParseServerSentEvents parses the events of a text/event-stream body,
+// the incomplete event at the end is dropped like the browsers do
+func ParseServerSentEvents(body string) (events []ServerSentEvent) {
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(body, "\n")
+
+	event := ServerSentEvent{}
+	var data []string
+	// the last line is incomplete, or an empty one after the last line break
+	for _, line := range lines[:len(lines)-1] {
+		if line == "" {
+			if len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				events = append(events, event)
+			}
+			event, data = ServerSentEvent{ID: event.ID}, nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			// it is a comment
+		case "id":
+			event.ID = value
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if retry, err := strconv.Atoi(value); err == nil {
+				event.Retry = retry
+			}
+		}
+	}
+	return
+}
+
+// FormatServerSentEvents writes the events as a text/event-stream body, it is the reverse of ParseServerSentEvents
+func FormatServerSentEvents(events []ServerSentEvent) string {
+	buf := new(strings.Builder)
+	var lastID string
+	for _, event := range events {
+		// the last ID is kept by the following events
+		if event.ID != lastID {
+			buf.WriteString("id: " + event.ID + "\n")
+			lastID = event.ID
+		}
+		if event.Event != "" {
+			buf.WriteString("event: " + event.Event + "\n")
+		}
+		if event.Retry > 0 {
+			buf.WriteString("retry: " + strconv.Itoa(event.Retry) + "\n")
+		}
+		for _, line := range strings.Split(event.Data, "\n") {
+			buf.WriteString("data: " + line + "\n")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
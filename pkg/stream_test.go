@@ -0,0 +1,114 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureBody(t *testing.T) {
+	type result struct {
+		data      string
+		truncated bool
+		calls     int
+	}
+	capture := func(body string, limit int64, read func(io.ReadCloser)) (r result) {
+		reader := pkg.CaptureBody(io.NopCloser(strings.NewReader(body)), limit, func(data []byte, truncated bool) {
+			r.data, r.truncated = string(data), truncated
+			r.calls++
+		})
+		read(reader)
+		return
+	}
+	readAll := func(reader io.ReadCloser) {
+		data, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", string(data))
+		assert.NoError(t, reader.Close())
+	}
+
+	assert.Equal(t, result{data: "hello world", calls: 1}, capture("hello world", 0, readAll))
+	assert.Equal(t, result{data: "hello world", calls: 1}, capture("hello world", 11, readAll))
+	// all the bytes are forwarded even if they are not recorded
+	assert.Equal(t, result{data: "hello", truncated: true, calls: 1}, capture("hello world", 5, readAll))
+
+	// the body is closed before reading all of it
+	assert.Equal(t, result{data: "hel", truncated: true, calls: 1}, capture("hello world", 0, func(reader io.ReadCloser) {
+		buf := make([]byte, 3)
+		_, _ = reader.Read(buf)
+		_ = reader.Close()
+	}))
+}
+
+func TestParseServerSentEvents(t *testing.T) {
+	events := pkg.ParseServerSentEvents(": keep-alive\n\nid: 1\nevent: created\ndata: {\"id\": 1}\n\n" +
+		"data: first\r\ndata:second\r\nretry: 3000\r\n\r\nevent: empty\n\nid: 3\ndata: incomplete")
+	assert.Equal(t, []pkg.ServerSentEvent{
+		{ID: "1", Event: "created", Data: `{"id": 1}`},
+		{ID: "1", Data: "first\nsecond", Retry: 3000},
+	}, events)
+	assert.Empty(t, pkg.ParseServerSentEvents(""))
+	assert.Equal(t, events, pkg.ParseServerSentEvents(pkg.FormatServerSentEvents(events)))
+	assert.Equal(t, "id: 1\nevent: created\ndata: {\"id\": 1}\n\nretry: 3000\ndata: first\ndata: second\n\n",
+		pkg.FormatServerSentEvents(events))
+
+	resp := &pkg.SimpleResponse{
+		Header: http.Header{"Content-Type": {"text/event-stream; charset=utf-8"}},
+		Body:   "data: hello\n\n",
+	}
+	assert.Equal(t, []pkg.ServerSentEvent{{Data: "hello"}}, resp.Events())
+	resp.Header.Set("Content-Type", "text/plain")
+	assert.Empty(t, resp.Events())
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "http://foo/api/events", nil)
+	reqAndResp := &pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       "data: hello\n\ndata: wor",
+		Truncated:  true,
+	}}
+
+	exporter := pkg.NewSampleExporter(true)
+	exporter.Add(reqAndResp)
+	// the complete events of the interrupted stream are kept
+	assert.Equal(t, "data: hello\n\n", exporter.TestSuite.Items[0].Expect.Body)
+	assert.Equal(t, http.StatusOK, exporter.TestSuite.Items[0].Expect.StatusCode)
+
+	entry := pkg.NewHAREntry(reqAndResp)
+	assert.Equal(t, "truncated", entry.Response.Content.Comment)
+	assert.Equal(t, []pkg.ServerSentEvent{{Data: "hello"}}, entry.Response.Content.Events)
+
+	restored, err := entry.ToRequestAndResponse()
+	assert.NoError(t, err)
+	assert.True(t, restored.Response.Truncated)
+
+	entry = pkg.NewHAREntry(&pkg.RequestAndResponse{Request: request, Response: &pkg.SimpleResponse{
+		Header: http.Header{}, Body: "hello",
+	}})
+	assert.Empty(t, entry.Response.Content.Comment)
+	restored, err = entry.ToRequestAndResponse()
+	assert.NoError(t, err)
+	assert.False(t, restored.Response.Truncated)
+}
@@ -0,0 +1,31 @@
+// generated by atest-collector, run it with: k6 run script.js
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+
+export const options = {
+  vus: 1,
+  iterations: 1,
+};
+
+export default function () {
+  let res;
+
+  res = http.request("POST", "http://foo/api/login", "{\"user\":\"rick\"}", {
+    headers: { "Authorization": __ENV.AUTHORIZATION, "Content-Type": "application/json" },
+    tags: { name: "POST /api/login" },
+  });
+  check(res, { "POST /api/login is 200": (r) => r.status === 200 });
+  sleep(1.90);
+
+  res = http.request("GET", "http://foo/api/users/1", null, {
+    headers: { "Authorization": __ENV.AUTHORIZATION },
+    tags: { name: "GET /api/users/{userId}" },
+  });
+  check(res, { "GET /api/users/{userId} is 200": (r) => r.status === 200 });
+
+  res = http.request("GET", "http://foo/api/users/2", null, {
+    headers: { "Authorization": __ENV.AUTHORIZATION },
+    tags: { name: "GET /api/users/{userId}" },
+  });
+  check(res, { "GET /api/users/{userId} is 404": (r) => r.status === 404 });
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ui
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NewHandler creates the HTTP handler of the web UI, the routes are:
+//
+//	GET   /                       the web UI
+//	GET   /api/v1/requests        lists the captured requests
+//	GET   /api/v1/requests/{id}   gets a captured request
+//	PATCH /api/v1/requests/{id}   changes the name, selection or expectation of a captured request
+//	GET   /api/v1/export          downloads the test suite of the selected requests
+func NewHandler(store *Store) http.Handler {
+	handler := &httpHandler{store: store}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handler.home)
+	mux.HandleFunc("/api/v1/requests", handler.list)
+	mux.HandleFunc("/api/v1/requests/", handler.request)
+	mux.HandleFunc("/api/v1/export", handler.export)
+	return mux
+}
+
+type httpHandler struct {
+	store *Store
+}
+
+func (h *httpHandler) home(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(frontPage)
+}
+
+func (h *httpHandler) list(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, h.store.List())
+}
+
+func (h *httpHandler) request(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/v1/requests/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var detail Detail
+	switch r.Method {
+	case http.MethodGet:
+		detail, err = h.store.Get(id)
+	case http.MethodPatch:
+		update := Update{}
+		if err = json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		detail, err = h.store.Update(id, update)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, detail)
+}
+
+func (h *httpHandler) export(w http.ResponseWriter, r *http.Request) {
+	suite, err := h.store.Export()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="sample.yaml"`)
+	_, _ = w.Write([]byte(suite))
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+//go:embed data/index.html
+var frontPage []byte
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ui_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	store := ui.NewStore(nil)
+	server := httptest.NewServer(ui.NewHandler(store))
+	defer server.Close()
+
+	call := func(method, path, body string) (int, http.Header, string) {
+		request, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		resp, err := http.DefaultClient.Do(request)
+		if !assert.NoError(t, err) {
+			return 0, nil, ""
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header, string(data)
+	}
+
+	code, header, data := call(http.MethodGet, "/", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, header.Get("Content-Type"), "text/html")
+	assert.Contains(t, data, "/api/v1/requests")
+	code, _, _ = call(http.MethodGet, "/unknown", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	add(store, http.MethodGet, "http://foo/api/users", "")
+	code, _, data = call(http.MethodGet, "/api/v1/requests", "")
+	assert.Equal(t, http.StatusOK, code)
+	var summaries []ui.Summary
+	assert.NoError(t, json.Unmarshal([]byte(data), &summaries))
+	assert.Len(t, summaries, 1)
+	code, _, _ = call(http.MethodPost, "/api/v1/requests", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, _, data = call(http.MethodPatch, "/api/v1/requests/1", `{"name":"users"}`)
+	assert.Equal(t, http.StatusOK, code)
+	var detail ui.Detail
+	assert.NoError(t, json.Unmarshal([]byte(data), &detail))
+	assert.Equal(t, "users", detail.Name)
+	assert.True(t, detail.Selected)
+
+	code, _, _ = call(http.MethodGet, "/api/v1/requests/2", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _, _ = call(http.MethodGet, "/api/v1/requests/foo", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _, _ = call(http.MethodPatch, "/api/v1/requests/1", "invalid")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _, _ = call(http.MethodDelete, "/api/v1/requests/1", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, header, data = call(http.MethodGet, "/api/v1/export", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, header.Get("Content-Disposition"), "attachment")
+	assert.Contains(t, data, "name: users")
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ui serves a web UI to browse and curate the captured requests before exporting them.
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// Summary is a captured request in the list
+type Summary struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"statusCode"`
+	StartedAt  time.Time `json:"startedAt"`
+	// Duration is in milliseconds
+	Duration int64 `json:"duration"`
+	Selected bool  `json:"selected"`
+}
+
+// Detail is a captured request with its headers, bodies and the expectation of the test case
+type Detail struct {
+	Summary
+	RequestHeader  http.Header           `json:"requestHeader"`
+	RequestBody    string                `json:"requestBody"`
+	ResponseHeader http.Header           `json:"responseHeader"`
+	ResponseBody   string                `json:"responseBody"`
+	Events         []pkg.ServerSentEvent `json:"events,omitempty"`
+	Truncated      bool                  `json:"truncated"`
+	Expect         Expect                `json:"expect"`
+}
+
+// Expect is the editable expectation of a test case
+type Expect struct {
+	StatusCode       int                    `json:"statusCode"`
+	Body             string                 `json:"body"`
+	BodyFieldsExpect map[string]interface{} `json:"bodyFieldsExpect,omitempty"`
+	Verify           []string               `json:"verify"`
+}
+
+// Update changes a captured request, the nil fields are not changed
+type Update struct {
+	Name     *string `json:"name"`
+	Selected *bool   `json:"selected"`
+	Expect   *Expect `json:"expect"`
+}
+
+type entry struct {
+	id          int
+	reqAndResp  *pkg.RequestAndResponse
+	requestBody []byte
+	testCase    testing.TestCase
+	name        string
+	selected    bool
+	expect      *Expect
+}
+
+// MaxEntries is the max number of the recent requests which are kept in the store
+const MaxEntries = 1000
+
+// Store keeps the captured requests and the changes of them, the selected ones are exported as a test suite.
+// It should be added as an event handle, then each test case is captured once.
+type Store struct {
+	lock         sync.Mutex
+	newExporter  func() *pkg.SampleExporter
+	headerPolicy *pkg.HeaderPolicy
+	entries      []*entry
+	nextID       int
+}
+
+// NewStore creates a store, the exporter is created for each export
+func NewStore(newExporter func() *pkg.SampleExporter) *Store {
+	if newExporter == nil {
+		newExporter = func() *pkg.SampleExporter {
+			return pkg.NewSampleExporter(false)
+		}
+	}
+	return &Store{newExporter: newExporter, headerPolicy: pkg.NewHeaderPolicy(), nextID: 1}
+}
+
+// WithHeaderPolicy sets the policy of the request headers which are shown, it should be the same as the exporter
+func (s *Store) WithHeaderPolicy(policy *pkg.HeaderPolicy) *Store {
+	if policy != nil {
+		s.headerPolicy = policy
+	}
+	return s
+}
+
+// Add is the EventHandle of the store, the new requests are selected. The oldest ones are removed once there
+// are more than MaxEntries.
+func (s *Store) Add(reqAndResp *pkg.RequestAndResponse) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// the default test case shows what will be exported without any change
+	exporter := s.newExporter()
+	exporter.Add(reqAndResp)
+	s.entries = append(s.entries, &entry{
+		id:          s.nextID,
+		reqAndResp:  reqAndResp,
+		requestBody: reqAndResp.ReadRequestBody(),
+		testCase:    exporter.TestSuite.Items[0],
+		selected:    true,
+	})
+	s.nextID++
+	if len(s.entries) > MaxEntries {
+		s.entries = s.entries[len(s.entries)-MaxEntries:]
+	}
+}
+
+// List returns all the captured requests
+func (s *Store) List() (summaries []Summary) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	summaries = make([]Summary, 0, len(s.entries))
+	for _, e := range s.entries {
+		summaries = append(summaries, e.summary())
+	}
+	return
+}
+
+// Get returns the detail of a captured request
+func (s *Store) Get(id int) (detail Detail, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var e *entry
+	if e, err = s.get(id); err == nil {
+		detail = e.detail(s.headerPolicy)
+	}
+	return
+}
+
+// Update changes a captured request
+func (s *Store) Update(id int, update Update) (detail Detail, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var e *entry
+	if e, err = s.get(id); err != nil {
+		return
+	}
+
+	if update.Name != nil {
+		e.name = *update.Name
+	}
+	if update.Selected != nil {
+		e.selected = *update.Selected
+	}
+	if update.Expect != nil {
+		expect := *update.Expect
+		e.expect = &expect
+	}
+	detail = e.detail(s.headerPolicy)
+	return
+}
+
+// Export exports the selected requests as a test suite with the changes
+func (s *Store) Export() (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// the requests are added in the captured order with their names, then the chain of them refers to the
+	// new names, and the deselected ones are not referred to
+	exporter := s.newExporter()
+	var selected []*entry
+	for _, e := range s.entries {
+		if e.selected {
+			exporter.AddWithName(e.reqAndResp, e.name)
+			selected = append(selected, e)
+		}
+	}
+	for i, e := range selected {
+		item := &exporter.TestSuite.Items[i]
+		if e.expect != nil {
+			item.Expect.StatusCode = e.expect.StatusCode
+			item.Expect.Body = e.expect.Body
+			item.Expect.BodyFieldsExpect = e.expect.BodyFieldsExpect
+			item.Expect.Verify = e.expect.Verify
+		}
+	}
+	return exporter.Export()
+}
+
+func (s *Store) get(id int) (*entry, error) {
+	if len(s.entries) > 0 {
+		// the IDs are increasing without gaps, the removed ones are before the first
+		if index := id - s.entries[0].id; index >= 0 && index < len(s.entries) {
+			return s.entries[index], nil
+		}
+	}
+	return nil, fmt.Errorf("request %d not found", id)
+}
+
+func (e *entry) summary() (summary Summary) {
+	r := e.reqAndResp.Request
+	summary = Summary{
+		ID:       e.id,
+		Name:     e.testCase.Name,
+		Method:   r.Method,
+		URL:      r.URL.String(),
+		Selected: e.selected,
+	}
+	if e.name != "" {
+		summary.Name = e.name
+	}
+	if resp := e.reqAndResp.Response; resp != nil {
+		summary.StatusCode = resp.StatusCode
+		summary.StartedAt = resp.StartedAt
+		summary.Duration = resp.Duration.Milliseconds()
+	}
+	return
+}
+
+// detail returns the detail of the entry, the request headers go through the header policy,
+// then the secrets are not shown
+func (e *entry) detail(headerPolicy *pkg.HeaderPolicy) (detail Detail) {
+	requestHeader := http.Header{}
+	for name, value := range headerPolicy.Apply(e.reqAndResp.Request.Header) {
+		requestHeader[name] = []string{value}
+	}
+	detail = Detail{
+		Summary:       e.summary(),
+		RequestHeader: requestHeader,
+		RequestBody:   string(e.requestBody),
+		Expect: Expect{
+			StatusCode:       e.testCase.Expect.StatusCode,
+			Body:             e.testCase.Expect.Body,
+			BodyFieldsExpect: e.testCase.Expect.BodyFieldsExpect,
+			Verify:           e.testCase.Expect.Verify,
+		},
+	}
+	if resp := e.reqAndResp.Response; resp != nil {
+		detail.ResponseHeader = resp.Header
+		detail.ResponseBody = resp.Body
+		detail.Events = resp.Events()
+		detail.Truncated = resp.Truncated
+	}
+	if e.expect != nil {
+		detail.Expect = *e.expect
+	}
+	return
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ui_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/linuxsuren/atest-ext-collector/pkg/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func add(store *ui.Store, method, api, body string) {
+	request, _ := http.NewRequest(method, api, bytes.NewBufferString(body))
+	store.Add(&pkg.RequestAndResponse{
+		Request: request,
+		Response: &pkg.SimpleResponse{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       `{"name":"rick"}`,
+			StartedAt:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Duration:   20 * time.Millisecond,
+		},
+	})
+}
+
+func TestStore(t *testing.T) {
+	store := ui.NewStore(func() *pkg.SampleExporter {
+		return pkg.NewSampleExporter(true)
+	})
+	add(store, http.MethodGet, "http://foo/api/users", "")
+	add(store, http.MethodPost, "http://foo/api/users", `{"name":"rick"}`)
+
+	summaries := store.List()
+	if assert.Len(t, summaries, 2) {
+		assert.Equal(t, 1, summaries[0].ID)
+		assert.Equal(t, "http://foo/api/users", summaries[0].URL)
+		assert.Equal(t, http.StatusOK, summaries[0].StatusCode)
+		assert.Equal(t, int64(20), summaries[0].Duration)
+		assert.True(t, summaries[0].Selected)
+	}
+
+	detail, err := store.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, detail.Method)
+	assert.Equal(t, `{"name":"rick"}`, detail.RequestBody)
+	assert.Equal(t, `{"name":"rick"}`, detail.ResponseBody)
+	assert.Equal(t, `{"name":"rick"}`, detail.Expect.Body)
+	assert.Equal(t, http.StatusOK, detail.Expect.StatusCode)
+
+	_, err = store.Get(3)
+	assert.Error(t, err)
+
+	events, _ := http.NewRequest(http.MethodGet, "http://foo/api/events", nil)
+	store.Add(&pkg.RequestAndResponse{Request: events, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       "data: hello\n\n",
+	}})
+	detail, err = store.Get(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []pkg.ServerSentEvent{{Data: "hello"}}, detail.Events)
+	assert.Equal(t, "data: hello\n\n", detail.Expect.Body)
+	_, err = store.Update(3, ui.Update{Selected: new(bool)})
+	assert.NoError(t, err)
+
+	_, err = store.Update(0, ui.Update{})
+	assert.Error(t, err)
+
+	name, selected := "createUser", false
+	detail, err = store.Update(2, ui.Update{Name: &name, Expect: &ui.Expect{
+		StatusCode: http.StatusCreated,
+		Verify:     []string{`data.name == "rick"`},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "createUser", detail.Name)
+	assert.Equal(t, http.StatusCreated, detail.Expect.StatusCode)
+	assert.Empty(t, detail.Expect.Body)
+
+	suite, err := store.Export()
+	assert.NoError(t, err)
+	assert.Contains(t, suite, "name: createUser")
+	assert.Contains(t, suite, `data.name == "rick"`)
+	assert.Contains(t, suite, "statusCode: 201")
+
+	_, err = store.Update(2, ui.Update{Selected: &selected})
+	assert.NoError(t, err)
+	suite, err = store.Export()
+	assert.NoError(t, err)
+	assert.NotContains(t, suite, "createUser")
+	assert.Contains(t, suite, "method: GET")
+}
+
+func TestStoreChain(t *testing.T) {
+	store := ui.NewStore(func() *pkg.SampleExporter {
+		return pkg.NewSampleExporter(false).WithChain(true)
+	})
+	login, _ := http.NewRequest(http.MethodPost, "http://foo/api/login", bytes.NewBufferString(`{"name":"rick"}`))
+	store.Add(&pkg.RequestAndResponse{Request: login, Response: &pkg.SimpleResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       `{"token":"eyJhbGciOiJIUzI1NiJ9"}`,
+	}})
+	users, _ := http.NewRequest(http.MethodGet, "http://foo/api/users", nil)
+	users.Header.Set("X-Token", "eyJhbGciOiJIUzI1NiJ9")
+	users.Header.Set("Authorization", "Bearer secret")
+	store.Add(&pkg.RequestAndResponse{Request: users, Response: &pkg.SimpleResponse{StatusCode: http.StatusOK}})
+
+	// the secrets are not shown
+	detail, err := store.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, `{{env "AUTHORIZATION"}}`, detail.RequestHeader.Get("Authorization"))
+
+	// the later test cases refer to the new name
+	name := "signIn"
+	_, err = store.Update(1, ui.Update{Name: &name})
+	assert.NoError(t, err)
+	suite, err := store.Export()
+	assert.NoError(t, err)
+	assert.Contains(t, suite, "{{.signIn.token}}")
+	assert.NotContains(t, suite, "{{.login.token}}")
+
+	// the deselected test cases are not referred to
+	selected := false
+	_, err = store.Update(1, ui.Update{Selected: &selected})
+	assert.NoError(t, err)
+	suite, err = store.Export()
+	assert.NoError(t, err)
+	assert.NotContains(t, suite, "signIn")
+	assert.Contains(t, suite, "eyJhbGciOiJIUzI1NiJ9")
+}
+
+func TestStoreLimit(t *testing.T) {
+	store := ui.NewStore(nil)
+	for i := 0; i <= ui.MaxEntries; i++ {
+		add(store, http.MethodGet, "http://foo/api/users", "")
+	}
+	summaries := store.List()
+	assert.Len(t, summaries, ui.MaxEntries)
+	assert.Equal(t, 2, summaries[0].ID)
+	_, err := store.Get(1)
+	assert.Error(t, err)
+	_, err = store.Get(ui.MaxEntries + 1)
+	assert.NoError(t, err)
+	_, err = store.Get(ui.MaxEntries + 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+)
+
+// the formats of the exported sessions
+const (
+	FormatJSON = "json"
+	FormatK6   = "k6"
+)
+
+// closeDelay is the time to wait for the last responses before closing the replayed connections
+const closeDelay = 1000
+
+// Exporter exports the WebSocket sessions as a JSON transcript or a k6 script
+type Exporter struct {
+	lock         sync.Mutex
+	format       string
+	headerPolicy *pkg.HeaderPolicy
+	sessions     []*Session
+}
+
+// NewExporter creates an exporter of the format
+func NewExporter(format string) (exporter *Exporter, err error) {
+	switch format {
+	case FormatJSON, FormatK6:
+		exporter = &Exporter{format: format, headerPolicy: pkg.NewHeaderPolicy()}
+	default:
+		err = fmt.Errorf("unsupported WebSocket format %q, supported: %s, %s", format, FormatJSON, FormatK6)
+	}
+	return
+}
+
+// WithHeaderPolicy sets the policy of the handshake headers
+func (e *Exporter) WithHeaderPolicy(policy *pkg.HeaderPolicy) *Exporter {
+	e.headerPolicy = policy
+	return e
+}
+
+// Add implements the pkg.Exporter, the HTTP requests are not exported
+func (e *Exporter) Add(_ *pkg.RequestAndResponse) {}
+
+// AddSession adds a closed session
+func (e *Exporter) AddSession(session *Session) {
+	session.lock.Lock()
+	session.Header = e.headerPolicy.Apply(session.RequestHeader)
+	session.lock.Unlock()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.sessions = append(e.sessions, session)
+}
+
+// Export exports the sessions
+func (e *Exporter) Export() (string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.format == FormatK6 {
+		return e.exportK6(), nil
+	}
+	data, err := json.MarshalIndent(append([]*Session{}, e.sessions...), "", "  ")
+	return string(data), err
+}
+
+func (e *Exporter) exportK6() string {
+	buf := new(strings.Builder)
+	buf.WriteString("// generated by atest-collector, run it with: k6 run script.js\n")
+	buf.WriteString("import ws from 'k6/ws';\nimport encoding from 'k6/encoding';\nimport { check } from 'k6';\n\n")
+	buf.WriteString("export const options = {\n  vus: 1,\n  iterations: 1,\n};\n\n")
+	buf.WriteString("export default function () {\n  let res;\n  let received;\n")
+	for _, session := range e.sessions {
+		buf.WriteString("\n")
+		e.writeK6Session(buf, session)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func (e *Exporter) writeK6Session(buf *strings.Builder, session *Session) {
+	var headers []string
+	for _, name := range pkg.SortedKeys(session.Header) {
+		value := pkg.ToJSString(session.Header[name])
+		if e.headerPolicy.IsSecret(name) && e.headerPolicy.Mode == pkg.SecretModeTemplate {
+			value = "__ENV." + pkg.GetSecretEnvName(name)
+		}
+		headers = append(headers, fmt.Sprintf("%s: %s", pkg.ToJSString(name), value))
+	}
+
+	// the connection is closed after the last message of the recording
+	var closeAt int64
+	received := 0
+	buf.WriteString("  received = 0;\n")
+	fmt.Fprintf(buf, "  res = ws.connect(%s, { headers: { %s } }, function (socket) {\n", pkg.ToJSString(session.URL), strings.Join(headers, ", "))
+	buf.WriteString("    socket.on('open', function () {\n")
+	for _, message := range session.Messages {
+		if message.Type == MessageClose {
+			continue
+		}
+		closeAt = message.Offset
+		if message.Direction == DirectionReceive {
+			received++
+			continue
+		}
+
+		switch message.Type {
+		case MessageText:
+			fmt.Fprintf(buf, "      socket.setTimeout(function () { socket.send(%s); }, %d);\n", pkg.ToJSString(message.Data), message.Offset)
+		case MessageBinary:
+			fmt.Fprintf(buf, "      socket.setTimeout(function () { socket.sendBinary(encoding.b64decode(%s)); }, %d);\n",
+				pkg.ToJSString(message.Data), message.Offset)
+		}
+	}
+	fmt.Fprintf(buf, "      socket.setTimeout(function () { socket.close(); }, %d);\n", closeAt+closeDelay)
+	buf.WriteString("    });\n")
+	buf.WriteString("    socket.on('message', function () { received++; });\n")
+	buf.WriteString("    socket.on('binaryMessage', function () { received++; });\n")
+	buf.WriteString("  });\n")
+	fmt.Fprintf(buf, "  check(res, { %s: (r) => r && r.status === %d });\n",
+		pkg.ToJSString(fmt.Sprintf("%s is %d", session.URL, session.Status)), session.Status)
+	fmt.Fprintf(buf, "  check(received, { %s: (n) => n >= %d });\n",
+		pkg.ToJSString(fmt.Sprintf("%s receives %d messages", session.URL, received)), received)
+}
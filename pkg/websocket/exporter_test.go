@@ -0,0 +1,85 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	_ "embed"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSession() *websocket.Session {
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	session := websocket.NewSession("ws://foo/ws/notifications", http.Header{
+		"Authorization":     {"Bearer token"},
+		"Origin":            {"http://foo"},
+		"Sec-Websocket-Key": {"dGhlIHNhbXBsZSBub25jZQ=="},
+	})
+	session.StartedAt = startedAt
+	session.Status = http.StatusSwitchingProtocols
+	session.Duration = 3000
+	session.Messages = []*websocket.Message{
+		{Direction: websocket.DirectionSend, Type: websocket.MessageText, Data: `{"subscribe":"orders"}`,
+			Time: startedAt.Add(20 * time.Millisecond), Offset: 20},
+		{Direction: websocket.DirectionReceive, Type: websocket.MessageText, Data: `{"event":"created"}`,
+			Time: startedAt.Add(1500 * time.Millisecond), Offset: 1500},
+		{Direction: websocket.DirectionSend, Type: websocket.MessageBinary, Data: "AAE=",
+			Time: startedAt.Add(1600 * time.Millisecond), Offset: 1600},
+		{Direction: websocket.DirectionReceive, Type: websocket.MessageClose, Code: 1000,
+			Time: startedAt.Add(3000 * time.Millisecond), Offset: 3000},
+	}
+	return session
+}
+
+func TestExporter(t *testing.T) {
+	_, err := websocket.NewExporter("fake")
+	assert.Error(t, err)
+
+	exporter, err := websocket.NewExporter(websocket.FormatJSON)
+	assert.NoError(t, err)
+	exporter.Add(nil)
+	exporter.AddSession(newSession())
+
+	result, err := exporter.Export()
+	assert.NoError(t, err)
+	var sessions []*websocket.Session
+	assert.NoError(t, json.Unmarshal([]byte(result), &sessions))
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, map[string]string{
+			"Authorization": `{{env "AUTHORIZATION"}}`,
+			"Origin":        "http://foo",
+		}, sessions[0].Header)
+		assert.Len(t, sessions[0].Messages, 4)
+		assert.Equal(t, int64(1500), sessions[0].Messages[1].Offset)
+	}
+
+	exporter, err = websocket.NewExporter(websocket.FormatK6)
+	assert.NoError(t, err)
+	exporter.AddSession(newSession())
+	result, err = exporter.Export()
+	assert.NoError(t, err)
+	assert.Equal(t, k6Script, result, result)
+}
+
+//go:embed testdata/k6_websocket.js
+var k6Script string
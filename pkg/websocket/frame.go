@@ -0,0 +1,166 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket
+
+import (
+	"encoding/binary"
+)
+
+// the opcodes of RFC 6455
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+)
+
+// MaxMessageSize is the max size of a recorded message, the rest of it is dropped
+const MaxMessageSize = 1 << 20
+
+// frameParser parses the frames of one direction, the bytes are written in any size of chunks.
+// It never returns an error, then the forwarding is not affected by the broken frames.
+type frameParser struct {
+	onMessage func(opcode byte, data []byte, truncated bool)
+
+	header []byte
+	// the current frame
+	inFrame   bool
+	opcode    byte
+	fin       bool
+	masked    bool
+	mask      [4]byte
+	maskIndex int
+	remaining uint64
+	// the current message, the control frames are not fragmented
+	messageType byte
+	message     []byte
+	truncated   bool
+	control     []byte
+}
+
+func (p *frameParser) Write(data []byte) (n int, err error) {
+	n = len(data)
+	for len(data) > 0 {
+		if !p.inFrame {
+			p.header = append(p.header, data...)
+			size, ok := p.parseHeader()
+			if !ok {
+				break
+			}
+			data, p.header = p.header[size:], nil
+			if p.remaining == 0 {
+				p.endFrame()
+			}
+			continue
+		}
+
+		chunk := data
+		if uint64(len(chunk)) > p.remaining {
+			chunk = chunk[:p.remaining]
+		}
+		data = data[len(chunk):]
+		p.remaining -= uint64(len(chunk))
+		p.appendPayload(chunk)
+		if p.remaining == 0 {
+			p.endFrame()
+		}
+	}
+	return
+}
+
+// parseHeader parses the header of a frame, it returns false if the header is incomplete
+func (p *frameParser) parseHeader() (size int, ok bool) {
+	header := p.header
+	if len(header) < 2 {
+		return
+	}
+
+	size = 2
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		size += 2
+	case 127:
+		size += 8
+	}
+	masked := header[1]&0x80 != 0
+	if masked {
+		size += 4
+	}
+	if len(header) < size {
+		return
+	}
+
+	switch length {
+	case 126:
+		length = uint64(binary.BigEndian.Uint16(header[2:4]))
+	case 127:
+		length = binary.BigEndian.Uint64(header[2:10])
+	}
+	p.inFrame = true
+	p.fin = header[0]&0x80 != 0
+	p.opcode = header[0] & 0x0f
+	p.masked = masked
+	p.maskIndex = 0
+	if masked {
+		copy(p.mask[:], header[size-4:size])
+	}
+	p.remaining = length
+	if p.opcode >= opClose {
+		p.control = nil
+	} else if p.opcode != opContinuation {
+		p.messageType = p.opcode
+		p.message = nil
+		p.truncated = false
+	}
+	ok = true
+	return
+}
+
+func (p *frameParser) appendPayload(chunk []byte) {
+	payload := make([]byte, len(chunk))
+	for i, c := range chunk {
+		if p.masked {
+			c ^= p.mask[p.maskIndex%4]
+			p.maskIndex++
+		}
+		payload[i] = c
+	}
+
+	if p.opcode >= opClose {
+		p.control = append(p.control, payload...)
+		return
+	}
+	if room := MaxMessageSize - len(p.message); room < len(payload) {
+		payload = payload[:room]
+		p.truncated = true
+	}
+	p.message = append(p.message, payload...)
+}
+
+func (p *frameParser) endFrame() {
+	p.inFrame = false
+	switch {
+	case p.opcode == opClose:
+		p.onMessage(opClose, p.control, false)
+	case p.opcode > opClose:
+		// ping and pong are not recorded
+	case p.fin && (p.messageType == opText || p.messageType == opBinary):
+		p.onMessage(p.messageType, p.message, p.truncated)
+		p.message = nil
+	}
+}
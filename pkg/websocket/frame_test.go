@@ -0,0 +1,96 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket_test
+
+import (
+	"encoding/binary"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeFrame encodes a frame of RFC 6455, the payload is masked if the mask is given
+func encodeFrame(fin bool, opcode byte, payload []byte, mask []byte) (frame []byte) {
+	frame = []byte{opcode, 0}
+	if fin {
+		frame[0] |= 0x80
+	}
+	switch length := len(payload); {
+	case length < 126:
+		frame[1] = byte(length)
+	case length <= 0xffff:
+		frame[1] = 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame[1] = 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	if len(mask) == 4 {
+		frame[1] |= 0x80
+		frame = append(frame, mask...)
+		for i, c := range payload {
+			frame = append(frame, c^mask[i%4])
+		}
+		return
+	}
+	return append(frame, payload...)
+}
+
+func TestRecorder(t *testing.T) {
+	session := websocket.NewSession("ws://foo/ws", http.Header{})
+	recorder := session.Recorder(websocket.DirectionSend)
+
+	// the masked frame is written byte by byte
+	for _, c := range encodeFrame(true, 0x1, []byte(`{"subscribe":"orders"}`), []byte{1, 2, 3, 4}) {
+		_, _ = recorder.Write([]byte{c})
+	}
+
+	// the fragmented message is interleaved with a ping
+	var data []byte
+	data = append(data, encodeFrame(false, 0x2, []byte{0, 1}, nil)...)
+	data = append(data, encodeFrame(true, 0x9, []byte("ping"), nil)...)
+	data = append(data, encodeFrame(true, 0x0, []byte{2}, nil)...)
+	data = append(data, encodeFrame(true, 0x1, []byte(strings.Repeat("a", 200)), nil)...)
+	data = append(data, encodeFrame(true, 0x1, []byte(strings.Repeat("b", 70000)), nil)...)
+	data = append(data, encodeFrame(true, 0x2, make([]byte, websocket.MaxMessageSize+10), []byte{5, 6, 7, 8})...)
+	data = append(data, encodeFrame(true, 0x8, append([]byte{0x03, 0xe8}, "bye"...), nil)...)
+	n, err := recorder.Write(data)
+	assert.NoError(t, err)
+	assert.Equal(t, len(data), n)
+
+	if assert.Len(t, session.Messages, 6) {
+		assert.Equal(t, websocket.MessageText, session.Messages[0].Type)
+		assert.Equal(t, `{"subscribe":"orders"}`, session.Messages[0].Data)
+		assert.Equal(t, websocket.DirectionSend, session.Messages[0].Direction)
+
+		assert.Equal(t, websocket.MessageBinary, session.Messages[1].Type)
+		assert.Equal(t, "AAEC", session.Messages[1].Data)
+
+		assert.Equal(t, strings.Repeat("a", 200), session.Messages[2].Data)
+		assert.Equal(t, strings.Repeat("b", 70000), session.Messages[3].Data)
+
+		assert.True(t, session.Messages[4].Truncated)
+		assert.Len(t, session.Messages[4].Data, (websocket.MaxMessageSize+2)/3*4)
+
+		assert.Equal(t, websocket.MessageClose, session.Messages[5].Type)
+		assert.Equal(t, 1000, session.Messages[5].Code)
+		assert.Equal(t, "bye", session.Messages[5].Data)
+	}
+}
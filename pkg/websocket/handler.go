@@ -0,0 +1,162 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Handler captures the WebSocket sessions of the plain HTTP proxy requests,
+// the other requests are served by the next handler
+type Handler struct {
+	next      http.Handler
+	onSession func(*Session)
+	// Dial connects to the WebSocket servers, it could be the dialer of the upstream proxy
+	Dial func(network, addr string) (net.Conn, error)
+	// Filter decides which URLs are captured, all of them are captured if it is nil
+	Filter func(u *url.URL) bool
+	// Auth checks the basic credentials of the Proxy-Authorization header, they are not checked if it is nil.
+	// It should be the same as the proxy, because the captured sessions do not go through it.
+	Auth func(user, password string) bool
+	// Realm is the realm of the Proxy-Authenticate header when the credentials are rejected
+	Realm string
+}
+
+// NewHandler creates a handler, the sessions are passed to the callback once they are closed
+func NewHandler(next http.Handler, onSession func(*Session)) *Handler {
+	return &Handler{next: next, onSession: onSession, Dial: net.Dial}
+}
+
+// IsUpgrade returns true if it is a WebSocket handshake request
+func IsUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func headerContains(header http.Header, name, value string) bool {
+	for _, item := range header.Values(name) {
+		for _, token := range strings.Split(item, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ServeHTTP implements the http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !IsUpgrade(r) || !r.URL.IsAbs() || r.URL.Scheme != "http" || (h.Filter != nil && !h.Filter(r.URL)) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	if h.Auth != nil && !h.authorized(r) {
+		w.Header().Set("Proxy-Authenticate", fmt.Sprintf("Basic realm=%q", h.Realm))
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+	h.capture(w, r)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	scheme, credentials, ok := strings.Cut(r.Header.Get("Proxy-Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return false
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(credentials))
+	if err != nil {
+		return false
+	}
+	user, password, ok := strings.Cut(string(data), ":")
+	return ok && h.Auth(user, password)
+}
+
+func (h *Handler) capture(w http.ResponseWriter, r *http.Request) {
+	addr := r.URL.Host
+	if r.URL.Port() == "" {
+		addr = net.JoinHostPort(r.URL.Hostname(), "80")
+	}
+	target, err := h.Dial("tcp", addr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer target.Close()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking is not supported", http.StatusInternalServerError)
+		return
+	}
+	client, clientReader, err := hijacker.Hijack()
+	if err != nil {
+		log.Println("failed to hijack the WebSocket connection", err)
+		return
+	}
+	defer client.Close()
+
+	wsURL := *r.URL
+	wsURL.Scheme = "ws"
+	session := NewSession(wsURL.String(), r.Header)
+
+	req := r.Clone(context.Background())
+	req.Header.Del("Proxy-Connection")
+	req.Header.Del("Proxy-Authorization")
+	// the compressed frames could not be recorded
+	req.Header.Del("Sec-WebSocket-Extensions")
+
+	targetReader := bufio.NewReader(target)
+	var resp *http.Response
+	if err = req.Write(target); err == nil {
+		resp, err = http.ReadResponse(targetReader, req)
+	}
+	if err != nil {
+		log.Println("failed to handshake with", wsURL.String(), err)
+		return
+	}
+	session.Status = resp.StatusCode
+	if err = resp.Write(client); err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		session.end()
+		h.onSession(session)
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst io.Writer, src io.Reader, direction string) {
+		_, _ = io.Copy(dst, io.TeeReader(src, session.Recorder(direction)))
+		done <- struct{}{}
+	}
+	go pipe(target, clientReader, DirectionSend)
+	go pipe(client, targetReader, DirectionReceive)
+
+	// one side is closed, then the other side is closed as well
+	<-done
+	_ = client.Close()
+	_ = target.Close()
+	<-done
+
+	session.end()
+	h.onSession(session)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	// a fake WebSocket server which replies a notification for each message, then closes the connection
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsUpgrade(r) || r.URL.Path == "/ws/fake" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Empty(t, r.Header.Get("Sec-WebSocket-Extensions"))
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+
+		header := make([]byte, 6)
+		_, _ = io.ReadFull(rw, header)
+		payload := make([]byte, header[1]&0x7f)
+		_, _ = io.ReadFull(rw, payload)
+		_, _ = conn.Write(encodeFrame(true, 0x1, []byte(`{"event":"created"}`), nil))
+		_, _ = conn.Write(encodeFrame(true, 0x8, []byte{0x03, 0xe8}, nil))
+	}))
+	defer server.Close()
+
+	sessions := make(chan *websocket.Session, 1)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := websocket.NewHandler(next, func(session *websocket.Session) {
+		sessions <- session
+	})
+	handler.Filter = func(u *url.URL) bool {
+		return strings.HasPrefix(u.Path, "/ws")
+	}
+	proxy := httptest.NewServer(handler)
+	defer proxy.Close()
+
+	t.Run("capture", func(t *testing.T) {
+		conn, err := net.Dial("tcp", proxy.Listener.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		_, _ = conn.Write([]byte("GET " + server.URL + "/ws/notifications HTTP/1.1\r\nHost: " + server.Listener.Addr().String() +
+			"\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nAuthorization: Bearer token\r\n" +
+			"Sec-WebSocket-Extensions: permessage-deflate\r\n\r\n"))
+		reader := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(reader, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		_, _ = conn.Write(encodeFrame(true, 0x1, []byte(`{"subscribe":"orders"}`), []byte{1, 2, 3, 4}))
+		_, _ = io.ReadAll(reader)
+
+		var session *websocket.Session
+		select {
+		case session = <-sessions:
+		case <-time.After(time.Second):
+			t.Fatal("the session is not captured")
+		}
+		assert.Equal(t, "ws://"+server.Listener.Addr().String()+"/ws/notifications", session.URL)
+		assert.Equal(t, http.StatusSwitchingProtocols, session.Status)
+		assert.Equal(t, "Bearer token", session.RequestHeader.Get("Authorization"))
+		if assert.Len(t, session.Messages, 3) {
+			assert.Equal(t, websocket.DirectionSend, session.Messages[0].Direction)
+			assert.Equal(t, `{"subscribe":"orders"}`, session.Messages[0].Data)
+			assert.Equal(t, websocket.DirectionReceive, session.Messages[1].Direction)
+			assert.Equal(t, `{"event":"created"}`, session.Messages[1].Data)
+			assert.Equal(t, websocket.MessageClose, session.Messages[2].Type)
+		}
+	})
+
+	t.Run("rejected handshake", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws/fake", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		resp, err := (&http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(mustParse(proxy.URL))}}).Do(req)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		session := <-sessions
+		assert.Equal(t, http.StatusBadRequest, session.Status)
+		assert.Empty(t, session.Messages)
+	})
+
+	t.Run("not captured", func(t *testing.T) {
+		for _, path := range []string{"/ws/fake", "/other"} {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+			if path == "/other" {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+			resp, err := (&http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(mustParse(proxy.URL))}}).Do(req)
+			assert.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		handler.Realm = "atest"
+		handler.Auth = func(user, password string) bool {
+			return user == "admin" && password == "secret"
+		}
+		defer func() {
+			handler.Auth = nil
+		}()
+
+		for _, credentials := range []string{"", "Basic fake", "Basic YWRtaW46d3Jvbmc=", "Bearer YWRtaW46c2VjcmV0"} {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws/notifications", nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			if credentials != "" {
+				req.Header.Set("Proxy-Authorization", credentials)
+			}
+			resp, err := (&http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(mustParse(proxy.URL))}}).Do(req)
+			assert.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusProxyAuthRequired, resp.StatusCode, credentials)
+			assert.Equal(t, `Basic realm="atest"`, resp.Header.Get("Proxy-Authenticate"))
+		}
+
+		// the rejected handshake is captured with the right credentials
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws/fake", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Proxy-Authorization", "Basic YWRtaW46c2VjcmV0")
+		resp, err := (&http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(mustParse(proxy.URL))}}).Do(req)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, (<-sessions).Status)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		handler.Dial = func(network, addr string) (net.Conn, error) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws/fake", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		resp, err := (&http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(mustParse(proxy.URL))}}).Do(req)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+}
+
+func mustParse(text string) *url.URL {
+	u, _ := url.Parse(text)
+	return u
+}
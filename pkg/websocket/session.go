@@ -0,0 +1,124 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the directions of the messages
+const (
+	DirectionSend    = "send"
+	DirectionReceive = "receive"
+)
+
+// the types of the messages
+const (
+	MessageText   = "text"
+	MessageBinary = "binary"
+	MessageClose  = "close"
+)
+
+// Session is a WebSocket connection, including the handshake and the messages of both directions
+type Session struct {
+	lock sync.Mutex
+
+	URL       string            `json:"url"`
+	Header    map[string]string `json:"header,omitempty"`
+	Status    int               `json:"status"`
+	StartedAt time.Time         `json:"startedAt"`
+	// Duration is the milliseconds between the handshake and closing the connection
+	Duration int64      `json:"duration"`
+	Messages []*Message `json:"messages"`
+
+	// RequestHeader is the original header of the handshake, the exporters apply the header policy on it
+	RequestHeader http.Header `json:"-"`
+}
+
+// Message is a WebSocket message, the binary data is base64 encoded
+type Message struct {
+	Direction string `json:"direction"`
+	Type      string `json:"type"`
+	Data      string `json:"data,omitempty"`
+	// Code is the status code of the close message
+	Code int       `json:"code,omitempty"`
+	Time time.Time `json:"time"`
+	// Offset is the milliseconds since the handshake
+	Offset    int64 `json:"offset"`
+	Truncated bool  `json:"truncated,omitempty"`
+}
+
+// NewSession creates a session of the handshake request
+func NewSession(url string, header http.Header) *Session {
+	return &Session{
+		URL:           url,
+		StartedAt:     time.Now(),
+		Messages:      []*Message{},
+		RequestHeader: header.Clone(),
+	}
+}
+
+// Recorder returns the writer which parses the frames of a direction, the messages are added into the session.
+// The bytes could be written in any size of chunks.
+func (s *Session) Recorder(direction string) io.Writer {
+	return &frameParser{onMessage: func(opcode byte, data []byte, truncated bool) {
+		s.add(newMessage(direction, opcode, data, truncated))
+	}}
+}
+
+func (s *Session) add(message *Message) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	message.Offset = message.Time.Sub(s.StartedAt).Milliseconds()
+	s.Messages = append(s.Messages, message)
+}
+
+// end marks the session as closed
+func (s *Session) end() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Duration = time.Since(s.StartedAt).Milliseconds()
+	// the messages of both directions are added concurrently
+	sort.SliceStable(s.Messages, func(i, j int) bool {
+		return s.Messages[i].Time.Before(s.Messages[j].Time)
+	})
+}
+
+func newMessage(direction string, opcode byte, data []byte, truncated bool) (message *Message) {
+	message = &Message{Direction: direction, Time: time.Now(), Truncated: truncated}
+	switch opcode {
+	case opText:
+		message.Type = MessageText
+		message.Data = string(data)
+	case opBinary:
+		message.Type = MessageBinary
+		message.Data = base64.StdEncoding.EncodeToString(data)
+	case opClose:
+		message.Type = MessageClose
+		if len(data) >= 2 {
+			message.Code = int(binary.BigEndian.Uint16(data[:2]))
+			message.Data = string(data[2:])
+		}
+	}
+	return
+}
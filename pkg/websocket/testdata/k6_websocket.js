@@ -0,0 +1,27 @@
+// generated by atest-collector, run it with: k6 run script.js
+import ws from 'k6/ws';
+import encoding from 'k6/encoding';
+import { check } from 'k6';
+
+export const options = {
+  vus: 1,
+  iterations: 1,
+};
+
+export default function () {
+  let res;
+  let received;
+
+  received = 0;
+  res = ws.connect("ws://foo/ws/notifications", { headers: { "Authorization": __ENV.AUTHORIZATION, "Origin": "http://foo" } }, function (socket) {
+    socket.on('open', function () {
+      socket.setTimeout(function () { socket.send("{\"subscribe\":\"orders\"}"); }, 20);
+      socket.setTimeout(function () { socket.sendBinary(encoding.b64decode("AAE=")); }, 1600);
+      socket.setTimeout(function () { socket.close(); }, 2600);
+    });
+    socket.on('message', function () { received++; });
+    socket.on('binaryMessage', function () { received++; });
+  });
+  check(res, { "ws://foo/ws/notifications is 101": (r) => r && r.status === 101 });
+  check(received, { "ws://foo/ws/notifications receives 1 messages": (n) => n >= 1 });
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OutputWriter rewrites the outputs of the exporters atomically while collecting.
+// It should be added as the last event handle, then it runs after all the exporters.
+type OutputWriter struct {
+	lock      sync.Mutex
+	outputs   map[string]Exporter
+	interval  time.Duration
+	lastFlush time.Time
+	dirty     bool
+}
+
+// NewOutputWriter creates an output writer, the outputs are written after
+// each request if the interval is zero. The outputs are written at the first
+// flush even if there is no request.
+func NewOutputWriter(interval time.Duration) *OutputWriter {
+	return &OutputWriter{
+		outputs:  map[string]Exporter{},
+		interval: interval,
+		dirty:    true,
+	}
+}
+
+// AddOutput adds an exporter with its output file
+func (w *OutputWriter) AddOutput(output string, exporter Exporter) {
+	w.outputs[output] = exporter
+}
+
+// Add is the EventHandle of the writer
+func (w *OutputWriter) Add(_ *RequestAndResponse) {
+	if err := w.Write(); err != nil {
+		log.Println("failed to write the outputs", err)
+	}
+}
+
+// Write marks the outputs as changed, then writes them if the flush interval is passed
+func (w *OutputWriter) Write() (err error) {
+	w.lock.Lock()
+	w.dirty = true
+	shouldFlush := w.interval <= 0 || time.Since(w.lastFlush) >= w.interval
+	w.lock.Unlock()
+
+	if shouldFlush {
+		err = w.Flush()
+	}
+	return
+}
+
+// Touch is the raw EventHandle of the writer, it marks the outputs as changed without writing them.
+// It is useful when the outputs depend on the duplicated requests, like the inferred schemas.
+func (w *OutputWriter) Touch(_ *RequestAndResponse) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.dirty = true
+}
+
+// Flush writes all the outputs if there are new requests
+func (w *OutputWriter) Flush() (err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.dirty {
+		return
+	}
+
+	outputs := make([]string, 0, len(w.outputs))
+	for output := range w.outputs {
+		outputs = append(outputs, output)
+	}
+	sort.Strings(outputs)
+
+	for _, output := range outputs {
+		var data string
+		if data, err = w.outputs[output].Export(); err == nil {
+			err = WriteFileAtomic(output, []byte(data), 0644)
+		}
+		if err != nil {
+			return
+		}
+	}
+	w.dirty = false
+	w.lastFlush = time.Now()
+	return
+}
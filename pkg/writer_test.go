@@ -0,0 +1,72 @@
+/*
+Copyright 2026 LinuxSuRen.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/atest-ext-collector/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputWriter(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "sample.yaml")
+	exporter := pkg.NewSampleExporter(false)
+
+	writer := pkg.NewOutputWriter(0)
+	writer.AddOutput(output, exporter)
+	assert.NoError(t, writer.Flush())
+	assert.FileExists(t, output)
+
+	request, _ := http.NewRequest(http.MethodGet, "http://foo/api/v1", nil)
+	reqAndResp := &pkg.RequestAndResponse{Request: request}
+	exporter.Add(reqAndResp)
+	writer.Add(reqAndResp)
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "http://foo/api/v1")
+
+	// the outputs are not written until the interval is reached
+	writer = pkg.NewOutputWriter(time.Hour)
+	output = filepath.Join(dir, "interval.yaml")
+	writer.AddOutput(output, exporter)
+	writer.Add(reqAndResp)
+	assert.FileExists(t, output)
+	assert.NoError(t, os.Remove(output))
+	writer.Add(reqAndResp)
+	assert.NoFileExists(t, output)
+	assert.NoError(t, writer.Flush())
+	assert.FileExists(t, output)
+
+	// the outputs are written at the next flush once they are touched
+	assert.NoError(t, os.Remove(output))
+	assert.NoError(t, writer.Flush())
+	assert.NoFileExists(t, output)
+	writer.Touch(reqAndResp)
+	assert.NoFileExists(t, output)
+	assert.NoError(t, writer.Flush())
+	assert.FileExists(t, output)
+
+	writer = pkg.NewOutputWriter(0)
+	writer.AddOutput(filepath.Join(dir, "fake", "sample.yaml"), exporter)
+	assert.Error(t, writer.Flush())
+}